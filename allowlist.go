@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/sslab-archive/block_lottery_cc/merkle"
+)
+
+type AllowlistLeaf string
+
+const (
+	ALLOWLIST_PARTICIPANT_UUID AllowlistLeaf = "ALLOWLIST_PARTICIPANT_UUID" // leaf is the participant UUID
+	ALLOWLIST_INFORMATION_HASH AllowlistLeaf = "ALLOWLIST_INFORMATION_HASH" // leaf is the hex sha256 of the participant information, such as an email
+)
+
+// MAX_ALLOWLIST_PROOF_STEPS bounds the proof of a participant, a tree of 2^64 leaves has 64 levels.
+const MAX_ALLOWLIST_PROOF_STEPS = 64
+
+func (e *Event) isAllowlisted() bool {
+	return e.AllowlistRoot != ""
+}
+
+// allowlistLeaf returns the allowlist leaf of the participant. the PII is read from the private data,
+// since it is separated from the participant before the participant is recorded.
+func (e *Event) allowlistLeaf(participant *Participant, privateData *ParticipantPrivateData) []byte {
+	if e.AllowlistLeaf == ALLOWLIST_INFORMATION_HASH {
+		hash := sha256.Sum256([]byte(privateData.Information))
+		return []byte(hex.EncodeToString(hash[:]))
+	}
+	return []byte(participant.UUID)
+}
+
+// CheckAllowlist verifies the participant is in the allowlist of the event with the merkle inclusion proof.
+func (e *Event) CheckAllowlist(participant *Participant, privateData *ParticipantPrivateData, proof []merkle.ProofStep) error {
+	if !e.isAllowlisted() {
+		return nil
+	}
+	if !merkle.VerifyProof(e.allowlistLeaf(participant, privateData), proof, e.AllowlistRoot) {
+		return ErrNotAllowlisted.WithDetail(participant.UUID)
+	}
+	return nil
+}
+
+// RotateAllowlist replaces the allowlist of a registered event, only by the event creator.
+// participants already in the event are kept. an empty root opens the event to everyone.
+func (e *Event) RotateAllowlist(root string, leaf AllowlistLeaf, tx Transaction) error {
+	if e.Status != STATUS_REGISTERD {
+		return ErrInvalidStatus.WithDetail("status is not registered")
+	}
+	if tx.ClientID == "" || tx.ClientID != e.EventCreateTx.ClientID {
+		return ErrUnauthorized.WithDetail("only the event creator can rotate the allowlist")
+	}
+
+	e.AllowlistRoot = strings.ToLower(root)
+	e.AllowlistLeaf = allowlistLeafOf(root, leaf)
+	e.AllowlistTx = tx
+	return nil
+}
+
+// allowlistLeafOf returns the leaf of the allowlist, ALLOWLIST_PARTICIPANT_UUID if not set. it is empty if there is no allowlist.
+func allowlistLeafOf(root string, leaf AllowlistLeaf) AllowlistLeaf {
+	if root == "" {
+		return ""
+	}
+	if leaf == "" {
+		return ALLOWLIST_PARTICIPANT_UUID
+	}
+	return leaf
+}
+
+// checkAllowlistArgs checks the root is a hex sha256 root and the leaf is defined.
+func checkAllowlistArgs(root string, leaf AllowlistLeaf) error {
+	if root == "" {
+		return nil
+	}
+	if b, err := hex.DecodeString(root); err != nil || len(b) != sha256.Size {
+		return ErrInvalidArg.WithField("allowlistRoot").WithDetail("allowlistRoot is not a hex encoded sha256 root")
+	}
+	switch leaf {
+	case "", ALLOWLIST_PARTICIPANT_UUID, ALLOWLIST_INFORMATION_HASH:
+	default:
+		return ErrUndefinedArg
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/sslab-archive/block_lottery_cc/merkle"
+)
+
+// allowlist returns the hex root of the leaves and the proof of each leaf.
+func allowlist(leaves ...string) (string, map[string][]merkle.ProofStep) {
+	data := make([][]byte, len(leaves))
+	leafHashes := make([][]byte, len(leaves))
+	for idx, leaf := range leaves {
+		data[idx] = []byte(leaf)
+		leafHashes[idx] = merkle.HashLeaf(data[idx])
+	}
+	proofs := make(map[string][]merkle.ProofStep)
+	for idx, leaf := range leaves {
+		proofs[leaf] = merkle.Proof(leafHashes, idx)
+	}
+	return merkle.RootHex(data), proofs
+}
+
+func TestAllowlist(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	root, proofs := allowlist("alice", "bob", "carol")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+		AllowlistRoot:  root,
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+	if event.AllowlistLeaf != ALLOWLIST_PARTICIPANT_UUID {
+		t.Fatal("allowlist leaf is not defaulted")
+	}
+
+	participate := func(txID string, uuid string, information string, proof []merkle.ProofStep) bool {
+		m.setClient(t, uuid)
+		m.setPrivateData(information, "")
+		_, ok := m.call(t, txID, "participateLotteryEvent", ParticipateLotteryRequest{
+			EventUUID:      event.UUID,
+			Participant:    Participant{UUID: uuid},
+			AllowlistProof: proof,
+		})
+		return ok
+	}
+	if !participate("participateTx1", "alice", "", proofs["alice"]) {
+		t.Fatal("invitee is rejected")
+	}
+	if participate("participateTx2", "dave", "", proofs["bob"]) {
+		t.Fatal("participant joined with the proof of another invitee")
+	}
+	if participate("participateTx3", "bob", "", nil) {
+		t.Fatal("invitee joined without a proof")
+	}
+
+	// only the creator rotates the root
+	emailHash := sha256.Sum256([]byte("dave@example.com"))
+	rotated, emailProofs := allowlist(hex.EncodeToString(emailHash[:]), "other")
+	request := UpdateAllowlistRequest{EventUUID: event.UUID, AllowlistRoot: rotated, AllowlistLeaf: ALLOWLIST_INFORMATION_HASH}
+	if _, ok = m.call(t, "rotateTx", "updateAllowlist", request); ok {
+		t.Fatal("participant rotated the allowlist")
+	}
+	m.setClient(t, "provider")
+	payload, ok = m.call(t, "rotateTx", "updateAllowlist", request)
+	if !ok {
+		t.FailNow()
+	}
+	unmarshalData(payload, event)
+	if event.AllowlistRoot != rotated || event.AllowlistTx.ID != "rotateTx" {
+		t.Fatal("rotation is not recorded")
+	}
+
+	if participate("participateTx4", "bob", "", proofs["bob"]) {
+		t.Fatal("participant joined with a proof of the previous root")
+	}
+	if !participate("participateTx5", "dave", "dave@example.com", emailProofs[hex.EncodeToString(emailHash[:])]) {
+		t.Fatal("invitee of the rotated root is rejected")
+	}
+	loaded, _ := LoadEventByUUID(m, event.UUID)
+	if len(loaded.Participants) != 2 {
+		t.Fatal("participants are not kept on rotation")
+	}
+
+	m.setClient(t, "provider")
+	if _, ok = m.call(t, "rotateTx2", "updateAllowlist", UpdateAllowlistRequest{EventUUID: event.UUID, AllowlistRoot: "root"}); ok {
+		t.Fatal("root out of the hex sha256 form is accepted")
+	}
+	if _, ok = m.call(t, "removeTx", "removeLotteryEvent", RemoveLotteryRequest{EventUUID: event.UUID}); !ok {
+		t.FailNow()
+	}
+	if _, ok = m.call(t, "rotateTx3", "updateAllowlist", UpdateAllowlistRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("allowlist of a removed event is rotated")
+	}
+}
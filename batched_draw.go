@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+type DrawPhase string
+
+const (
+	DRAW_PHASE_TRACING    DrawPhase = "TRACING"    // undoing the swaps of the shuffle for the prize slots
+	DRAW_PHASE_COLLECTING DrawPhase = "COLLECTING" // loading the participants at the traced positions
+	DRAW_PHASE_READY      DrawPhase = "READY"      // every prize slot is collected, finalizeDraw writes the winners
+	DRAW_PHASE_FINALIZED  DrawPhase = "FINALIZED"
+)
+
+const (
+	DEFAULT_DRAW_CHUNK_SIZE = 10000
+	MAX_DRAW_CHUNK_SIZE     = 100000
+)
+
+// DrawState is the cursor of a batched draw, recorded in composite key ( event_{eventUUID}~drawState ).
+// beginDraw fixes the seed and the participants, each continueDraw moves the cursor by a bounded chunk,
+// and finalizeDraw awards the collected participants the same way a single transaction draw does.
+type DrawState struct {
+	DocType       DocType `json:"docType"`
+	SchemaVersion int64   `json:"schemaVersion"`
+
+	EventUUID string    `json:"eventUUID"`
+	Phase     DrawPhase `json:"phase"`
+	SeedHash  string    `json:"seedHash"`
+
+	// participants are only added or withdrawn before the deadline,
+	// so the drawing ordinals 0 .. ParticipantNum-1 are fixed once the draw begins.
+	// finalizeDraw checks the participants hash of the event is still the one the draw began with.
+	ParticipantNum   int64  `json:"participantNum"`
+	ParticipantsHash string `json:"participantsHash"`
+
+	Trace    draw.PrefixTrace `json:"trace"`
+	Shuffled []Participant    `json:"shuffled"` // participants at the traced positions, in shuffle order
+
+	BeginTx Transaction `json:"beginTx"`
+	LastTx  Transaction `json:"lastTx"`
+}
+
+func MakeDrawStateKey(stubInterface shim.ChaincodeStubInterface, eventUUID string) (string, error) {
+	return stubInterface.CreateCompositeKey(MakeKeyByUUID(eventUUID), []string{"drawState"})
+}
+
+// LoadDrawState loads the batched draw of the event, the state is empty if the draw is not begun.
+func LoadDrawState(stubInterface shim.ChaincodeStubInterface, eventUUID string) (*DrawState, error) {
+	key, err := MakeDrawStateKey(stubInterface, eventUUID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := stubInterface.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	state := &DrawState{}
+	if b == nil {
+		return state, nil
+	}
+
+	err = UnmarshalRecord(DOC_TYPE_DRAW_STATE, b, state)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *DrawState) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
+	key, err := MakeDrawStateKey(stubInterface, s.EventUUID)
+	if err != nil {
+		return err
+	}
+	b, err := s.ToLedgerBinary()
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(key, b)
+}
+
+func (s DrawState) ToLedgerBinary() ([]byte, error) {
+	s.DocType = DOC_TYPE_DRAW_STATE
+	s.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_DRAW_STATE)
+	return json.Marshal(s)
+}
+
+// BeginDraw fixes the seed of the event and starts the batched draw of its participants.
+// the event must be loaded with LoadEventHeaderByUUID and indexed by ordinal.
+func (e *Event) BeginDraw(tx Transaction) (*DrawState, error) {
+	if !e.OrdinalIndexed {
+		return nil, ErrInvalidStatus.WithDetail("participants are not indexed by ordinal. migrate the event first")
+	}
+	err := e.startDraw(tx)
+	if err != nil {
+		return nil, err
+	}
+	e.Status = STATUS_DRAWING
+
+	drawingNum := e.ParticipantNum
+	if drawingNum > e.MaxParticipant {
+		drawingNum = e.MaxParticipant
+	}
+	state := &DrawState{
+		EventUUID:        e.UUID,
+		Phase:            DRAW_PHASE_TRACING,
+		SeedHash:         e.SeedHash,
+		ParticipantNum:   drawingNum,
+		ParticipantsHash: e.ParticipantsHash,
+		Trace:            *draw.NewPrefixTrace(int(drawingNum), int(prizeSlotNum(e.Prizes))),
+		Shuffled:         make([]Participant, 0),
+		BeginTx:          tx,
+		LastTx:           tx,
+	}
+	state.advance()
+	return state, nil
+}
+
+// Continue processes up to chunkSize swaps or participants of the current phase.
+func (s *DrawState) Continue(stubInterface shim.ChaincodeStubInterface, event *Event, chunkSize int, tx Transaction) error {
+	switch s.Phase {
+	case DRAW_PHASE_TRACING:
+		s.Trace.Step(s.SeedHash, chunkSize)
+	case DRAW_PHASE_COLLECTING:
+		ordinals := s.Trace.Positions[len(s.Shuffled):]
+		if len(ordinals) > chunkSize {
+			ordinals = ordinals[:chunkSize]
+		}
+		participants, err := LoadParticipantsAt(stubInterface, event, ordinals)
+		if err != nil {
+			return err
+		}
+		for _, ordinal := range ordinals {
+			s.Shuffled = append(s.Shuffled, participants[ordinal])
+		}
+	default:
+		return ErrInvalidStatus.WithDetail("draw is " + string(s.Phase))
+	}
+
+	s.LastTx = tx
+	s.advance()
+	return nil
+}
+
+// advance moves to the next phase when the current one is done.
+func (s *DrawState) advance() {
+	if s.Phase == DRAW_PHASE_TRACING && s.Trace.Done() {
+		s.Phase = DRAW_PHASE_COLLECTING
+	}
+	if s.Phase == DRAW_PHASE_COLLECTING && len(s.Shuffled) == len(s.Trace.Positions) {
+		s.Phase = DRAW_PHASE_READY
+	}
+}
+
+// FinalizeDraw awards the collected participants and closes the batched draw.
+func (e *Event) FinalizeDraw(state *DrawState, tx Transaction) error {
+	if e.Status != STATUS_DRAWING {
+		return ErrInvalidStatus.WithDetail("status is not drawing. begin the draw first")
+	}
+	if state.Phase != DRAW_PHASE_READY {
+		return ErrInvalidStatus.WithDetail("draw is " + string(state.Phase) + ". continue the draw until it is ready")
+	}
+	if state.ParticipantsHash != e.ParticipantsHash {
+		return ErrInvalidStatus.WithDetail("participants are changed after the draw began")
+	}
+
+	awardPrizes(e.Prizes, state.Shuffled, tx)
+	e.Status = STATUS_DRAWN
+	e.DrawTx = tx
+
+	state.Phase = DRAW_PHASE_FINALIZED
+	state.LastTx = tx
+	return nil
+}
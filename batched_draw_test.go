@@ -0,0 +1,204 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// a draw split into chunks of any size writes the same winners and alternates as a single transaction draw.
+func TestBatchedDraw(t *testing.T) {
+	for _, participantNum := range []int{0, 5, 40} {
+		for _, chunkSize := range []int{1, 7, 1000} {
+			m, _ := newDrawStub(t, participantNum, true)
+			tx := Transaction{ID: "drawTx", Timestamp: 200}
+
+			expected, err := LoadEventByUUID(m, "drawEvent")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = expected.Draw(tx); err != nil {
+				t.Fatal(err)
+			}
+
+			event, err := LoadEventHeaderByUUID(m, "drawEvent")
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, err := event.BeginDraw(Transaction{ID: "beginTx", Timestamp: 200})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Status != STATUS_DRAWING || state.SeedHash != expected.SeedHash {
+				t.Fatal("seed is not fixed")
+			}
+
+			for chunk := 0; state.Phase != DRAW_PHASE_READY; chunk++ {
+				if chunk > participantNum*2+2 {
+					t.Fatalf("%d participants, chunk %d : draw is not ready", participantNum, chunkSize)
+				}
+				if err = event.FinalizeDraw(state, tx); !ErrInvalidStatus.Is(err) {
+					t.Fatal("draw is finalized before it is ready")
+				}
+				if err = state.Continue(m, event, chunkSize, Transaction{ID: "continueTx" + strconv.Itoa(chunk)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = event.FinalizeDraw(state, tx); err != nil {
+				t.Fatal(err)
+			}
+			for idx, prize := range event.Prizes {
+				expectedPrize := expected.Prizes[idx]
+				if !sameParticipants(prize.Winners, expectedPrize.Winners) || !sameParticipants(prize.Alternates, expectedPrize.Alternates) {
+					t.Fatalf("%d participants, chunk %d : prize %s is drawn differently", participantNum, chunkSize, prize.UUID)
+				}
+				if len(prize.Claims) != len(expectedPrize.Claims) {
+					t.Fatalf("%d participants, chunk %d : claims of prize %s are not opened", participantNum, chunkSize, prize.UUID)
+				}
+			}
+			if event.Status != STATUS_DRAWN || state.Phase != DRAW_PHASE_FINALIZED {
+				t.Fatal("draw is not finalized")
+			}
+			if err = state.Continue(m, event, chunkSize, tx); !ErrInvalidStatus.Is(err) {
+				t.Fatal("finalized draw is continued")
+			}
+		}
+	}
+}
+
+// events drawn in key order are migrated before a batched draw.
+func TestBatchedDrawRequiresOrdinals(t *testing.T) {
+	_, event := newDrawStub(t, 5, false)
+	if _, err := event.BeginDraw(Transaction{ID: "beginTx", Timestamp: 200}); !ErrInvalidStatus.Is(err) {
+		t.Fatal("draw of an event without ordinals is begun")
+	}
+}
+
+func TestBatchedDrawTransactions(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 2, AlternateNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	for _, uuid := range []string{"participant3", "participant1", "participant4", "participant2"} {
+		m.setClient(t, uuid)
+		m.setPrivateData(uuid+"@example.com", "")
+		_, ok = m.call(t, "participateTx"+uuid, "participateLotteryEvent", ParticipateLotteryRequest{
+			EventUUID:   event.UUID,
+			Participant: Participant{UUID: uuid},
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+
+	m.setClient(t, "provider")
+	time.Sleep(2 * time.Second)
+	expected, _ := LoadEventByUUID(m, event.UUID)
+	if expected.Participants[0].UUID != "participant3" {
+		t.Fatal("participants are not loaded in the participation order")
+	}
+
+	if _, ok = m.call(t, "continueTx", "continueDraw", ContinueDrawRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("draw is continued before it begins")
+	}
+
+	// only the event creator or an administrator draws the event, and picks the seed inputs
+	m.setClient(t, "participant1")
+	if _, ok = m.call(t, "drawTx", "drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "participantHash"}); ok {
+		t.Fatal("participant drew the event")
+	}
+	if _, ok = m.call(t, "beginTx", "beginDraw", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "participantHash"}); ok {
+		t.Fatal("participant began the draw")
+	}
+	m.setClient(t, "provider")
+	if _, ok = m.call(t, "beginTx", "beginDraw", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "providerHash"}); !ok {
+		t.FailNow()
+	}
+	if _, ok = m.call(t, "drawTx", "drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "providerHash"}); ok {
+		t.Fatal("event is drawn twice")
+	}
+
+	m.setClient(t, "participant1")
+	if _, ok = m.call(t, "continueTx", "continueDraw", ContinueDrawRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("participant continued the draw")
+	}
+
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	state := &DrawState{}
+	for chunk := 0; state.Phase != DRAW_PHASE_READY; chunk++ {
+		if chunk > 10 {
+			t.Fatal("draw is not ready")
+		}
+		payload, ok = m.call(t, "continueTx"+strconv.Itoa(chunk), "continueDraw", ContinueDrawRequest{EventUUID: event.UUID, ChunkSize: 1})
+		if !ok {
+			t.FailNow()
+		}
+		unmarshalData(payload, state)
+	}
+	m.setClient(t, "participant1")
+	if _, ok = m.call(t, "finalizeTx", "finalizeDraw", FinalizeDrawRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("participant finalized the draw")
+	}
+	m.setClient(t, "provider")
+	if _, ok = m.call(t, "finalizeTx", "finalizeDraw", FinalizeDrawRequest{EventUUID: event.UUID}); !ok {
+		t.FailNow()
+	}
+
+	expected.ServiceProviderHash = "providerHash"
+	expected.Draw(Transaction{Timestamp: time.Now().Unix()})
+	drawn, _ := LoadEventByUUID(m, event.UUID)
+	if drawn.Status != STATUS_DRAWN || drawn.DrawTx.ID != "finalizeTx" {
+		t.Fatal("draw is not finalized")
+	}
+	if !sameParticipants(drawn.Prizes[0].Winners, expected.Prizes[0].Winners) || !sameParticipants(drawn.Prizes[0].Alternates, expected.Prizes[0].Alternates) {
+		t.Fatal("batched draw differs from the single transaction draw")
+	}
+	if _, ok = m.call(t, "finalizeTx2", "finalizeDraw", FinalizeDrawRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("draw is finalized twice")
+	}
+}
+
+func TestFinalizeDrawParticipantsChanged(t *testing.T) {
+	event := newDrawTestEvent(0)
+	event.OrdinalIndexed = true
+	for _, uuid := range []string{"p0", "p1", "p2"} {
+		if err := event.Participate(Participant{UUID: uuid, Commitment: uuid + "Commitment"}, 50); err != nil {
+			t.Fatal(err)
+		}
+	}
+	joined := event.ParticipantsHash
+
+	state, err := event.BeginDraw(Transaction{ID: "beginTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined == "" || state.ParticipantsHash != joined {
+		t.Fatal("participants are not fixed by the draw")
+	}
+
+	// a participant slipped in after the draw began fails the draw
+	state.Phase = DRAW_PHASE_READY
+	event.chainParticipantsHash("participate", &Participant{UUID: "late"})
+	if err = event.FinalizeDraw(state, Transaction{ID: "finalizeTx", Timestamp: 300}); !ErrInvalidStatus.Is(err) {
+		t.Fatal("draw is finalized with other participants")
+	}
+	event.ParticipantsHash = joined
+	if err = event.FinalizeDraw(state, Transaction{ID: "finalizeTx", Timestamp: 300}); err != nil {
+		t.Fatal(err)
+	}
+}
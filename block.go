@@ -1,5 +1,9 @@
 package main
 
+import (
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
 const (
 	BITCOIN BlockType = "BITCOIN"
 	EOS     BlockType = "EOS"
@@ -9,9 +13,10 @@ type BlockType string
 
 type BlockInfo struct {
 	BlockType BlockType `json:"blockType"`
-	Hash      string    `json:"hash"`
-	Timestamp int64     `json:"time"`
-	Height    int64     `json:"height"`
+	Hash      string    `json:"hash" metadata:",optional"`
+	Timestamp int64     `json:"time" metadata:",optional"`
+	Height    int64     `json:"height" metadata:",optional"`
+	Header    string    `json:"header" metadata:",optional"` // hex encoded block header, recorded for draw certificates
 }
 
 func NewBitcoinBlock() BlockInfo {
@@ -22,3 +27,26 @@ func NewBitcoinBlock() BlockInfo {
 		Height:    0,
 	}
 }
+
+// CheckHeader checks the header is the header of the block hash and meets its proof of work.
+// only bitcoin headers are checked, and a block without the header is accepted.
+func (b BlockInfo) CheckHeader() error {
+	if b.Header == "" {
+		return nil
+	}
+	if b.BlockType != BITCOIN {
+		return ErrInvalidArg.WithField("targetBlock.header").WithDetail("only bitcoin headers are recorded")
+	}
+
+	header, err := draw.ParseBitcoinHeader(b.Header)
+	if err != nil {
+		return ErrInvalidArg.WithField("targetBlock.header").WithDetail(err.Error())
+	}
+	if header.Hash != b.Hash {
+		return ErrInvalidArg.WithField("targetBlock.header").WithDetail("header is not the header of the block hash")
+	}
+	if err = header.CheckProofOfWork(); err != nil {
+		return ErrInvalidArg.WithField("targetBlock.header").WithDetail(err.Error())
+	}
+	return nil
+}
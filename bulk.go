@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// TRANSIENT_BULK_CHUNK_KEY is the transient map key of the chunk data in uploadBulkChunk proposals.
+const TRANSIENT_BULK_CHUNK_KEY = "bulkChunk"
+
+// BulkRowResult is the result of a participant of a bulkParticipate transaction.
+type BulkRowResult struct {
+	Row             int           `json:"row"`
+	ParticipantUUID string        `json:"participantUUID"`
+	Accepted        bool          `json:"accepted"`
+	Error           *LotteryError `json:"error,omitempty"` // reason of the rejection
+}
+
+type BulkParticipateResult struct {
+	EventUUID   string          `json:"eventUUID"`
+	AcceptedNum int             `json:"acceptedNum"`
+	RejectedNum int             `json:"rejectedNum"`
+	Rows        []BulkRowResult `json:"rows"`
+}
+
+type BulkChunkReceipt struct {
+	EventUUID  string `json:"eventUUID"`
+	FileHash   string `json:"fileHash"`
+	ChunkIndex int32  `json:"chunkIndex"`
+	Size       int    `json:"size"`
+}
+
+// MakeBulkChunkKey makes the private data key of a chunk of a participant file ( event_{eventUUID}~bulkChunks~{fileHash}~{chunkIndex} ).
+func MakeBulkChunkKey(stubInterface shim.ChaincodeStubInterface, eventUUID string, fileHash string, chunkIndex int32) (string, error) {
+	return stubInterface.CreateCompositeKey(MakeKeyByUUID(eventUUID), []string{"bulkChunks", fileHash, fmt.Sprintf("%06d", chunkIndex)})
+}
+
+// SaveBulkChunk records a chunk of a participant file in the private data collection of the event,
+// since the file has participant PII. the chunk is taken from the transient map only, as args are recorded in the block.
+func SaveBulkChunk(stubInterface shim.ChaincodeStubInterface, event *Event, config *LotteryConfig, request UploadBulkChunkRequest) (*BulkChunkReceipt, error) {
+	if int64(request.ChunkIndex) >= config.MaxBulkChunks {
+		return nil, ErrInvalidArg.WithField("chunkIndex").WithDetail("a file has at most " + strconv.FormatInt(config.MaxBulkChunks, 10) + " chunks")
+	}
+	if request.Data != "" {
+		return nil, ErrInvalidArg.WithField("data").WithDetail("PII is sent in the " + TRANSIENT_BULK_CHUNK_KEY + " transient key")
+	}
+
+	transient, err := stubInterface.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	data, exist := transient[TRANSIENT_BULK_CHUNK_KEY]
+	if !exist {
+		return nil, ErrRequired(TRANSIENT_BULK_CHUNK_KEY)
+	}
+	if int64(len(data)) > config.MaxBulkChunkSize {
+		return nil, ErrInvalidArg.WithField("data").WithDetail("a chunk has at most " + strconv.FormatInt(config.MaxBulkChunkSize, 10) + " bytes")
+	}
+
+	key, err := MakeBulkChunkKey(stubInterface, event.UUID, request.FileHash, request.ChunkIndex)
+	if err != nil {
+		return nil, err
+	}
+	err = stubInterface.PutPrivateData(event.GetPrivateCollection(), key, data)
+	if err != nil {
+		return nil, err
+	}
+	return &BulkChunkReceipt{EventUUID: event.UUID, FileHash: request.FileHash, ChunkIndex: request.ChunkIndex, Size: len(data)}, nil
+}
+
+// LoadBulkFile joins the uploaded chunks of the participant file and checks them with the hash committed by the upload.
+func LoadBulkFile(stubInterface shim.ChaincodeStubInterface, event *Event, fileHash string, chunkNum int32) ([]Participant, error) {
+	file := make([]byte, 0)
+	for chunkIndex := int32(0); chunkIndex < chunkNum; chunkIndex++ {
+		key, err := MakeBulkChunkKey(stubInterface, event.UUID, fileHash, chunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := stubInterface.GetPrivateData(event.GetPrivateCollection(), key)
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			return nil, ErrPrivateDataNotFound.WithDetail("chunk " + strconv.Itoa(int(chunkIndex)) + " of " + fileHash)
+		}
+		file = append(file, chunk...)
+	}
+
+	hash := sha256.Sum256(file)
+	if hex.EncodeToString(hash[:]) != fileHash {
+		return nil, ErrInvalidArg.WithField("fileHash").WithDetail("uploaded chunks do not match the file hash")
+	}
+
+	participants := make([]Participant, 0)
+	err := json.Unmarshal(file, &participants)
+	if err != nil {
+		return nil, ErrInvalidArg.WithField("fileHash").WithDetail("file is not a JSON array of participants")
+	}
+	return participants, nil
+}
+
+// RemoveBulkFile removes the uploaded chunks of an imported participant file, so a file is imported once.
+func RemoveBulkFile(stubInterface shim.ChaincodeStubInterface, event *Event, fileHash string, chunkNum int32) error {
+	for chunkIndex := int32(0); chunkIndex < chunkNum; chunkIndex++ {
+		key, err := MakeBulkChunkKey(stubInterface, event.UUID, fileHash, chunkIndex)
+		if err != nil {
+			return err
+		}
+		err = stubInterface.DelPrivateData(event.GetPrivateCollection(), key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkParticipate adds the participants to the event as participateLotteryEvent does, loading the event once.
+// every row is checked on its own, rejected rows are reported and the accepted ones are written.
+func BulkParticipate(stubInterface shim.ChaincodeStubInterface, event *Event, participants []Participant, tx Transaction) (*BulkParticipateResult, error) {
+	result := &BulkParticipateResult{EventUUID: event.UUID, Rows: make([]BulkRowResult, 0, len(participants))}
+	accepted := make([]Participant, 0, len(participants))
+	privateData := make([]*ParticipantPrivateData, 0, len(participants))
+	for row, participant := range participants {
+		rowResult := BulkRowResult{Row: row, ParticipantUUID: participant.UUID}
+		data, err := addBulkRow(stubInterface, event, participant, tx)
+		if err != nil {
+			rowResult.Error = ToLotteryError(err)
+			result.RejectedNum++
+		} else {
+			rowResult.Accepted = true
+			accepted = append(accepted, participant)
+			privateData = append(privateData, data)
+			result.AcceptedNum++
+		}
+		result.Rows = append(result.Rows, rowResult)
+	}
+
+	err := event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	for idx, participant := range accepted {
+		key, err := MakeParticipantKey(stubInterface, event.UUID, participant.UUID)
+		if err != nil {
+			return nil, err
+		}
+		err = privateData[idx].SaveToLedger(stubInterface, event.GetPrivateCollection(), key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func addBulkRow(stubInterface shim.ChaincodeStubInterface, event *Event, participant Participant, tx Transaction) (*ParticipantPrivateData, error) {
+	v := new(Validation)
+	v.UUID("UUID", participant.UUID)
+	v.participantInformation("participant", participant.Information, participant.AuthInformation)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	participant = Participant{UUID: participant.UUID, Information: participant.Information, AuthInformation: participant.AuthInformation, ParticipateTx: tx}
+	privateData, err := participant.commitPrivateData(stubInterface, &ParticipantPrivateData{
+		Information:     participant.Information,
+		AuthInformation: participant.AuthInformation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows are not invoked by the participants, only their fields are checked
+	err = event.CheckFieldEligibility(&participant, privateData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.CheckExclusionRules(stubInterface, participant.UUID, tx.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	err = event.Participate(participant, tx.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return privateData, nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newBulkEvent(t *testing.T, m *identityStub, maxParticipant int64) *Event {
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: maxParticipant,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+	return event
+}
+
+// uploadBulkFile uploads the participants as a file of one chunk, sent in the transient map.
+func uploadBulkFile(t *testing.T, m *identityStub, eventUUID string, participants []Participant) string {
+	file, _ := json.Marshal(participants)
+	hash := sha256.Sum256(file)
+	fileHash := hex.EncodeToString(hash[:])
+
+	m.transient = map[string][]byte{TRANSIENT_BULK_CHUNK_KEY: file}
+	if _, ok := m.call(t, "uploadTx", "uploadBulkChunk", UploadBulkChunkRequest{EventUUID: eventUUID, FileHash: fileHash, ChunkIndex: 0}); !ok {
+		t.FailNow()
+	}
+	return fileHash
+}
+
+func TestBulkParticipate(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	event := newBulkEvent(t, m, 4)
+
+	m.setClient(t, "existing")
+	if _, ok := m.call(t, "participateTx", "participateLotteryEvent", ParticipateLotteryRequest{
+		EventUUID:   event.UUID,
+		Participant: Participant{UUID: "existing"},
+	}); !ok {
+		t.FailNow()
+	}
+
+	request := BulkParticipateRequest{
+		EventUUID: event.UUID,
+		Participants: []Participant{
+			{UUID: "p1"},
+			{UUID: "existing"},
+			{UUID: ""},
+			{UUID: "p1"},
+			{UUID: "p2", Information: "p2@example.com"},
+			{UUID: "p3"},
+			{UUID: "p4"},
+		},
+	}
+	if _, ok := m.call(t, "bulkTx", "bulkParticipate", request); ok {
+		t.Fatal("participant added others in bulk")
+	}
+
+	// rows in args are recorded in the block, the PII is sent in the uploaded file
+	m.setClient(t, "provider")
+	if _, ok := m.call(t, "bulkTx", "bulkParticipate", request); ok {
+		t.Fatal("rows with PII in args are added")
+	}
+	request.Participants[4].Information = ""
+	payload, ok := m.call(t, "bulkTx", "bulkParticipate", request)
+	if !ok {
+		t.FailNow()
+	}
+	result := &BulkParticipateResult{}
+	unmarshalData(payload, result)
+
+	expected := []string{"", ErrDuplicateParticipant.Name, ErrInvalidRequest.Name, ErrDuplicateParticipant.Name, "", "", ErrParticipantLimitExceeded.Name}
+	if len(result.Rows) != len(expected) || result.AcceptedNum != 3 || result.RejectedNum != 4 {
+		t.Fatalf("unexpected result : %+v", result)
+	}
+	for row, name := range expected {
+		rowResult := result.Rows[row]
+		if rowResult.Row != row || rowResult.Accepted != (name == "") {
+			t.Fatalf("row %d : %+v", row, rowResult)
+		}
+		if name != "" && rowResult.Error.Name != name {
+			t.Fatalf("row %d : expected %s, got %s", row, name, rowResult.Error.Name)
+		}
+	}
+
+	// accepted rows are written as participateLotteryEvent writes them, in one transaction
+	loaded, _ := LoadEventByUUID(m, event.UUID)
+	if loaded.ParticipantNum != 4 || loaded.Participants[3].UUID != "p3" || loaded.Participants[3].Ordinal != 3 {
+		t.Fatal("accepted participants are not written in the draw order")
+	}
+	privateData, _ := LoadParticipantPrivateData(m, loaded, "p2")
+	if privateData == nil {
+		t.Fatal("private data is not written")
+	}
+	if commitment, _ := privateData.MakeCommitment(); commitment != loaded.Participants[2].Commitment {
+		t.Fatal("participant is not committed to the private data")
+	}
+}
+
+func TestBulkParticipateFile(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	event := newBulkEvent(t, m, 10)
+
+	file, _ := json.Marshal([]Participant{{UUID: "p1", Information: "p1@example.com"}, {UUID: "p2"}, {UUID: "p3"}})
+	hash := sha256.Sum256(file)
+	fileHash := hex.EncodeToString(hash[:])
+	chunks := []string{string(file[:20]), string(file[20:])}
+
+	if _, ok := m.call(t, "uploadTx", "uploadBulkChunk", UploadBulkChunkRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkIndex: 0, Data: chunks[0]}); ok {
+		t.Fatal("chunk in args is uploaded")
+	}
+	for idx, chunk := range chunks {
+		m.transient = map[string][]byte{TRANSIENT_BULK_CHUNK_KEY: []byte(chunk)}
+		if _, ok := m.call(t, "uploadTx", "uploadBulkChunk", UploadBulkChunkRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkIndex: int32(idx)}); !ok {
+			t.FailNow()
+		}
+	}
+	key, _ := MakeBulkChunkKey(m, event.UUID, fileHash, 0)
+	if m.State[key] != nil || m.PvtState[DEFAULT_PII_COLLECTION][key] == nil {
+		t.Fatal("chunk is not kept in the private data")
+	}
+
+	// the rows limit is configured on the ledger by administrators
+	config := SetLotteryConfigRequest{MaxBulkRows: 2, MaxBulkChunkSize: 1024, MaxBulkChunks: 4}
+	if _, ok := m.call(t, "configTx", "setLotteryConfig", config); ok {
+		t.Fatal("client without the admin attribute configured the lottery")
+	}
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	if _, ok := m.call(t, "configTx", "setLotteryConfig", config); !ok {
+		t.FailNow()
+	}
+
+	m.setClient(t, "provider")
+	request := BulkParticipateRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkNum: 2}
+	if _, ok := m.call(t, "bulkTx", "bulkParticipate", request); ok {
+		t.Fatal("batch over the configured limit is added")
+	}
+	if _, ok := m.call(t, "bulkTx", "bulkParticipate", BulkParticipateRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkNum: 1}); ok {
+		t.Fatal("file not matched with the hash is added")
+	}
+
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	config.MaxBulkRows = 10
+	if _, ok := m.call(t, "configTx", "setLotteryConfig", config); !ok {
+		t.FailNow()
+	}
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "bulkTx", "bulkParticipate", request)
+	if !ok {
+		t.FailNow()
+	}
+	result := &BulkParticipateResult{}
+	unmarshalData(payload, result)
+	if result.AcceptedNum != 3 {
+		t.Fatalf("unexpected result : %+v", result)
+	}
+	if m.PvtState[DEFAULT_PII_COLLECTION][key] != nil {
+		t.Fatal("imported chunks are not removed")
+	}
+	loaded, _ := LoadEventByUUID(m, event.UUID)
+	privateData, _ := LoadParticipantPrivateData(m, loaded, "p1")
+	if privateData == nil || privateData.Information != "p1@example.com" || loaded.Participants[0].Information != "" {
+		t.Fatal("PII is not kept in the private data")
+	}
+
+	// chunks over the configured size are rejected
+	m.transient = map[string][]byte{TRANSIENT_BULK_CHUNK_KEY: make([]byte, 1025)}
+	if _, ok = m.call(t, "uploadTx", "uploadBulkChunk", UploadBulkChunkRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkIndex: 0}); ok {
+		t.Fatal("chunk over the configured size is uploaded")
+	}
+}
+
+func TestMaxParticipantConfig(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+	request := CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: DEFAULT_MAX_PARTICIPANT + 1,
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+	}
+	if _, ok := m.call(t, "createTx", "createLotteryEvent", request); ok {
+		t.Fatal("event over the default participant limit is created")
+	}
+
+	// administrators raise the limit up to MAX_PARTICIPANT
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	config := SetLotteryConfigRequest{MaxBulkRows: 10, MaxBulkChunkSize: 1024, MaxBulkChunks: 4, MaxParticipant: MAX_PARTICIPANT + 1}
+	if _, ok := m.call(t, "configTx", "setLotteryConfig", config); ok {
+		t.Fatal("participant limit over MAX_PARTICIPANT is configured")
+	}
+	config.MaxParticipant = MAX_PARTICIPANT
+	if _, ok := m.call(t, "configTx", "setLotteryConfig", config); !ok {
+		t.FailNow()
+	}
+
+	m.setClient(t, "provider")
+	request.MaxParticipant = MAX_PARTICIPANT
+	if _, ok := m.call(t, "createTx", "createLotteryEvent", request); !ok {
+		t.Fatal("event within the configured participant limit is rejected")
+	}
+}
+
+func TestBulkParticipateEligibility(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	createEvent := func(txID string, rules []EligibilityRule) *Event {
+		payload, ok := m.call(t, txID, "createLotteryEvent", CreateLotteryRequest{
+			EventName:        "event",
+			DeadlineTime:     time.Now().Unix() + 1000,
+			MaxParticipant:   10,
+			DrawTypes:        []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+			Prizes:           []Prize{{Title: "prize", WinnerNum: 1}},
+			EligibilityRules: rules,
+
+			ServiceProviderHash: "providerHash",
+		})
+		if !ok {
+			t.FailNow()
+		}
+		event := &Event{}
+		unmarshalData(payload, event)
+		return event
+	}
+
+	// rows are not invoked by the participants, rules on the client identity cannot check them
+	event := createEvent("createTx1", []EligibilityRule{{Type: ELIGIBLE_MSP, Values: []string{"Org1MSP"}}})
+	if _, ok := m.call(t, "bulkTx1", "bulkParticipate", BulkParticipateRequest{EventUUID: event.UUID, Participants: []Participant{{UUID: "p1"}}}); ok {
+		t.Fatal("rows are checked with the identity of the creator")
+	}
+
+	event = createEvent("createTx2", []EligibilityRule{{Type: ELIGIBLE_PARTICIPANT_FIELD, Field: "information", Pattern: `[^@]+@example\.com`}})
+	fileHash := uploadBulkFile(t, m, event.UUID, []Participant{
+		{UUID: "p1", Information: "p1@example.com"},
+		{UUID: "p2", Information: "p2@evil.com"},
+	})
+	payload, ok := m.call(t, "bulkTx2", "bulkParticipate", BulkParticipateRequest{EventUUID: event.UUID, FileHash: fileHash, ChunkNum: 1})
+	if !ok {
+		t.FailNow()
+	}
+	result := &BulkParticipateResult{}
+	unmarshalData(payload, result)
+	if !result.Rows[0].Accepted || result.Rows[1].Accepted || result.Rows[1].Error.Field != "eligibilityRules[0]" {
+		t.Fatalf("rows are not checked with their own fields : %+v", result)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+	"github.com/sslab-archive/block_lottery_cc/merkle"
+)
+
+// environment variables of the draw certificate issuer.
+// certificates are not signed without the issuer key, the endorsement of the query is the proof then.
+const (
+	ENV_ISSUER_KEY  = "LOTTERY_ISSUER_KEY"  // path of the PEM ECDSA private key
+	ENV_ISSUER_CERT = "LOTTERY_ISSUER_CERT" // path of the PEM certificate of the key, optional
+)
+
+// MakeDrawCertificate returns the certificate of the drawn event.
+func (e *Event) MakeDrawCertificate() (*draw.Certificate, error) {
+	if e.Status != STATUS_DRAWN {
+		return nil, ErrInvalidStatus.WithDetail("event is not drawn")
+	}
+
+	drawTypes := make([]string, len(e.DrawTypes))
+	for idx, drawType := range e.DrawTypes {
+		drawTypes[idx] = string(drawType)
+	}
+
+	participants := make([]draw.CertificateParticipant, 0)
+	for _, participant := range e.drawingParticipants() {
+		participants = append(participants, draw.CertificateParticipant{UUID: participant.UUID, Commitment: participant.Commitment})
+	}
+
+	prizes := make([]draw.CertificatePrize, len(e.Prizes))
+	for idx, prize := range e.Prizes {
+		prizes[idx] = draw.CertificatePrize{
+			UUID:         prize.UUID,
+			Title:        prize.Title,
+			WinnerNum:    prize.WinnerNum,
+			AlternateNum: prize.AlternateNum,
+			Winners:      participantUUIDs(prize.Winners),
+			Alternates:   participantUUIDs(prize.Alternates),
+			Disqualified: make([]string, 0),
+		}
+		for _, disqualification := range prize.Disqualifications {
+			prizes[idx].Disqualified = append(prizes[idx].Disqualified, disqualification.Participant.UUID)
+		}
+	}
+
+	certificate := &draw.Certificate{
+		Version:        draw.CERTIFICATE_VERSION,
+		EventUUID:      e.UUID,
+		EventName:      e.EventName,
+		DeadlineTime:   e.DeadlineTime,
+		MaxParticipant: e.MaxParticipant,
+		DrawTypes:      drawTypes,
+		Participants:   participants,
+		Seed: draw.CertificateSeed{
+			BlockType:           string(e.TargetBlock.BlockType),
+			BlockHeight:         e.TargetBlock.Height,
+			BlockHash:           e.TargetBlock.Hash,
+			BlockHeader:         e.TargetBlock.Header,
+			ServiceProviderHash: e.ServiceProviderHash,
+			SeedHash:            e.SeedHash,
+		},
+		Algorithms: draw.CurrentAlgorithms(),
+		Prizes:     prizes,
+		DrawTxID:   e.DrawTx.ID,
+		DrawTime:   e.DrawTx.Timestamp,
+	}
+	certificate.ParticipantRoot = merkle.RootHex(certificate.ParticipantLeaves())
+	return certificate, nil
+}
+
+func participantUUIDs(participants []Participant) []string {
+	uuids := make([]string, len(participants))
+	for idx, participant := range participants {
+		uuids[idx] = participant.UUID
+	}
+	return uuids
+}
+
+// SignDrawCertificate signs the certificate with the issuer key of the environment.
+// the certificate is returned unsigned when the issuer key is not set.
+func SignDrawCertificate(certificate *draw.Certificate) (*draw.SignedCertificate, error) {
+	signed := &draw.SignedCertificate{Certificate: *certificate}
+
+	keyPath := os.Getenv(ENV_ISSUER_KEY)
+	if keyPath == "" {
+		return signed, nil
+	}
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := draw.ParseIssuerKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	signerPEM := ""
+	if certPath := os.Getenv(ENV_ISSUER_CERT); certPath != "" {
+		certPEM, err := ioutil.ReadFile(certPath)
+		if err != nil {
+			return nil, err
+		}
+		signerPEM = string(certPEM)
+	}
+
+	err = signed.Sign(key, signerPEM)
+	if err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+// bitcoin mainnet block 1
+const (
+	testBlockHash   = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+	testBlockHeader = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+)
+
+// writeIssuerKey writes a new issuer key and sets the environment.
+func writeIssuerKey(t *testing.T, dir string) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "issuer_key.pem")
+	ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), 0600)
+	os.Setenv(ENV_ISSUER_KEY, keyPath)
+	return key
+}
+
+func TestGetDrawCertificate(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+	txNum := 0
+	invoke := func(function string, request interface{}) pb.Response {
+		b, _ := json.Marshal(request)
+		txNum++
+		m.args = []string{function, string(b)}
+		m.MockTransactionStart("tx" + strconv.Itoa(txNum))
+		defer m.MockTransactionEnd("tx" + strconv.Itoa(txNum))
+		return new(LotteryChaincode).Invoke(m)
+	}
+
+	res := invoke("createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_BLOCK_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 2, AlternateNum: 1}},
+		TargetBlock:    BlockInfo{BlockType: BITCOIN, Height: 1},
+	})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := &Event{}
+	unmarshalData(res.Payload, event)
+	for i := 0; i < 5; i++ {
+		res = invoke("participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "participant" + strconv.Itoa(i)}})
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+	}
+
+	res = invoke("getDrawCertificate", GetDrawCertificateRequest{EventUUID: event.UUID})
+	if lotteryErr, _ := ParseErrorResponse(res.Message); !ErrInvalidStatus.Is(lotteryErr) {
+		t.Errorf("certificate of not drawn event : %s", res.Message)
+	}
+
+	time.Sleep(2 * time.Second)
+	res = invoke("drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, TargetBlock: BlockInfo{BlockType: BITCOIN, Hash: testBlockHash, Height: 1, Header: testBlockHeader[:152] + "00000000"}})
+	if lotteryErr, _ := ParseErrorResponse(res.Message); !ErrInvalidArg.Is(lotteryErr) || lotteryErr.Field != "targetBlock.header" {
+		t.Errorf("header of another block must be rejected : %s", res.Message)
+	}
+	res = invoke("drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, TargetBlock: BlockInfo{BlockType: BITCOIN, Hash: testBlockHash, Height: 1, Header: testBlockHeader}})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	dir, err := ioutil.TempDir("", "lottery_issuer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv(ENV_ISSUER_KEY)
+	key := writeIssuerKey(t, dir)
+
+	res = invoke("getDrawCertificate", GetDrawCertificateRequest{EventUUID: event.UUID})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	signed := &draw.SignedCertificate{}
+	unmarshalData(res.Payload, signed)
+	if err = signed.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+	signerKey, _ := draw.ParseSignerPublicKey(signed.Signer)
+	if signerKey.X.Cmp(key.X) != 0 {
+		t.Error("certificate must be signed by the issuer key")
+	}
+
+	certificate := signed.Certificate
+	if certificate.Seed.BlockHeader != testBlockHeader || len(certificate.Participants) != 5 || certificate.ParticipantRoot == "" || certificate.DrawTxID == "" {
+		t.Errorf("unexpected certificate : %+v", certificate)
+	}
+	if len(certificate.Prizes[0].Winners) != 2 || len(certificate.Prizes[0].Alternates) != 1 {
+		t.Errorf("unexpected prize : %+v", certificate.Prizes[0])
+	}
+
+	signed.Certificate.Prizes[0].Winners[0] = "participant9"
+	if signed.VerifySignature() == nil {
+		t.Error("signature of modified certificate must not be valid")
+	}
+}
@@ -3,22 +3,45 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"os"
 )
 
-var logger = shim.NewLogger("LotteryCC")
-var ErrArgsNum = shim.Error("request args num is not matched")
-var ErrArgsUnmarshal = shim.Error("request args is not match request object")
-var ErrUnknownArgs = shim.Error("request args is not defined")
+var logger = NewLogger("LotteryCC")
+var ErrArgsNum = ErrorResponse(ErrInvalidArgsNum)
+var ErrArgsUnmarshal = ErrorResponse(ErrInvalidArgsJSON)
+var ErrUnknownArgs = ErrorResponse(ErrUndefinedArg)
 
 func ErrArgsRequired(argName string) pb.Response {
-	return shim.Error("required arg is not exist : " + argName)
+	return ErrorResponse(ErrRequired(argName))
 }
 
+// LotteryChaincode serves the legacy operations with the response envelope,
+// and passes the other invocations to the contract functions of LotteryContract.
 type LotteryChaincode struct {
 }
 
+var lotteryContract = NewLotteryContract()
+var lotteryContractChaincode = newLotteryContractChaincode()
+
+func newLotteryContractChaincode() *contractapi.ContractChaincode {
+	chaincode, err := NewLotteryContractChaincode(lotteryContract)
+	if err != nil {
+		panic("cannot make contract chaincode : " + err.Error())
+	}
+	return chaincode
+}
+
+// contractResponse returns the result of a contract function in the response envelope.
+func contractResponse(data interface{}, err error) pb.Response {
+	if err != nil {
+		return ErrorResponse(err)
+	}
+	return SuccessResponse(data)
+}
+
 func (l *LotteryChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 
 	// Initialize launching lottery
@@ -35,38 +58,56 @@ func (l *LotteryChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	return shim.Success(nil)
 }
 
+// lotteryRouter is the registry of every chaincode operation.
+var lotteryRouter = newLotteryRouter()
+
+func newLotteryRouter() *Router {
+	router := NewRouter()
+	router.Register(Operation{Name: "createLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).createLotteryEvent})
+	router.Register(Operation{Name: "queryLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).queryLotteryEvent})
+	router.Register(Operation{Name: "queryLotteryHistory", ArgsNum: 1, Handler: (*LotteryChaincode).queryLotteryHistory})
+	router.Register(Operation{Name: "queryParticipantPrivateData", ArgsNum: 1, Handler: (*LotteryChaincode).queryParticipantPrivateData})
+	router.Register(Operation{Name: "participateLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).participateLotteryEvent})
+	router.Register(Operation{Name: "bulkParticipate", ArgsNum: 1, Handler: (*LotteryChaincode).bulkParticipate})
+	router.Register(Operation{Name: "uploadBulkChunk", ArgsNum: 1, Handler: (*LotteryChaincode).uploadBulkChunk})
+	router.Register(Operation{Name: "withdrawParticipation", ArgsNum: 1, Handler: (*LotteryChaincode).withdrawParticipation})
+	router.Register(Operation{Name: "removeLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).removeLotteryEvent})
+	router.Register(Operation{Name: "updateAllowlist", ArgsNum: 1, Handler: (*LotteryChaincode).updateAllowlist})
+	router.Register(Operation{Name: "eraseParticipantData", ArgsNum: 1, Handler: (*LotteryChaincode).eraseParticipantData})
+	router.Register(Operation{Name: "drawLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).drawLotteryEvent})
+	router.Register(Operation{Name: "beginDraw", ArgsNum: 1, Handler: (*LotteryChaincode).beginDraw})
+	router.Register(Operation{Name: "continueDraw", ArgsNum: 1, Handler: (*LotteryChaincode).continueDraw})
+	router.Register(Operation{Name: "finalizeDraw", ArgsNum: 1, Handler: (*LotteryChaincode).finalizeDraw})
+	router.Register(Operation{Name: "disqualifyWinner", ArgsNum: 1, Handler: (*LotteryChaincode).disqualifyWinner})
+	router.Register(Operation{Name: "addLotteryRound", ArgsNum: 1, Handler: (*LotteryChaincode).addLotteryRound})
+	router.Register(Operation{Name: "drawLotteryRound", ArgsNum: 1, Handler: (*LotteryChaincode).drawLotteryRound})
+	router.Register(Operation{Name: "verifyLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).verifyLotteryEvent})
+	router.Register(Operation{Name: "verifyLotteryRound", ArgsNum: 1, Handler: (*LotteryChaincode).verifyLotteryRound})
+	router.Register(Operation{Name: "getDrawCertificate", ArgsNum: 1, Handler: (*LotteryChaincode).getDrawCertificate})
+	router.Register(Operation{Name: "createLotteryTemplate", ArgsNum: 1, Handler: (*LotteryChaincode).createLotteryTemplate})
+	router.Register(Operation{Name: "updateLotteryTemplate", ArgsNum: 1, Handler: (*LotteryChaincode).updateLotteryTemplate})
+	router.Register(Operation{Name: "queryLotteryTemplate", ArgsNum: 1, Handler: (*LotteryChaincode).queryLotteryTemplate})
+	router.Register(Operation{Name: "spawnFromTemplate", ArgsNum: 1, Handler: (*LotteryChaincode).spawnFromTemplate})
+	router.Register(Operation{Name: "claimPrize", ArgsNum: 1, Handler: (*LotteryChaincode).claimPrize})
+	router.Register(Operation{Name: "closeClaims", ArgsNum: 1, Handler: (*LotteryChaincode).closeClaims})
+	router.Register(Operation{Name: "setLotteryConfig", ArgsNum: 1, Handler: (*LotteryChaincode).setLotteryConfig})
+	router.Register(Operation{Name: "migrateEvents", ArgsNum: 1, Handler: (*LotteryChaincode).migrateEvents})
+	/* todo : impl this.
+	router.Register(Operation{Name: "updateLotteryEvent", ArgsNum: 1, Handler: (*LotteryChaincode).updateLotteryEvent})
+	*/
+	return router
+}
+
+// Invoke accepts the operation name as the function ( direct form ),
+// or "invoke" with the operation name in args[0] ( legacy form ).
+// other functions, such as CreateLotteryEvent or org.hyperledger.fabric:GetMetadata, are contract functions.
 func (l *LotteryChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	logger.Info("Blockchain Lottery Chaincode! invoke method###")
-	function, args := stub.GetFunctionAndParameters()
-	logger.Info("Invoked method is " + args[0])
-	logger.Info(args)
-	if function != "invoke" {
-		return shim.Error("Unknown function call: " + function)
-	}
-
-	switch args[0] {
-	case "createLotteryEvent":
-		return l.createLotteryEvent(stub, args)
-	case "queryLotteryEvent":
-		return l.queryLotteryEvent(stub, args)
-		/* todo : impl this.
-		case "removeLotteryEvent":
-			return l.createLotteryEvent(stub, args)
-		*/
-		/* todo : impl this.
-		case "updateLotteryEvent":
-			return l.createLotteryEvent(stub, args)
-		*/
-	case "participateLotteryEvent":
-		return l.participateLotteryEvent(stub, args)
-	case "drawLotteryEvent":
-		return l.drawLotteryEvent(stub, args)
-		/* todo: impl this.
-		case "verifyLotteryEvent":
-			return l.draw(stub, args)
-		*/
-	}
-	return shim.Error("Unknown Invoke Method")
+	function, _ := stub.GetFunctionAndParameters()
+	if lotteryRouter.Handles(function) {
+		return lotteryRouter.Route(l, stub)
+	}
+	return lotteryContractChaincode.Invoke(stub)
 }
 
 // args[0] = function name
@@ -77,70 +118,13 @@ func (l *LotteryChaincode) createLotteryEvent(stubInterface shim.ChaincodeStubIn
 	}
 
 	// unmarshal request args
-	createLotteryRequest := &CreateLotteryRequest{}
-
-	err := json.Unmarshal([]byte(args[1]), createLotteryRequest)
-	logger.Debug(createLotteryRequest)
+	request := &CreateLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		logger.Error(err)
 		return ErrArgsUnmarshal
 	}
 
-	// check args is valid
-	for _, drawType := range createLotteryRequest.DrawTypes {
-		switch drawType {
-		case DRAW_BLOCK_HASH:
-			if createLotteryRequest.TargetBlock.Height == 0 {
-				return ErrArgsRequired("targetBlock")
-			}
-			break
-		case DRAW_SERVICE_PROVIDER_HASH:
-			if createLotteryRequest.ServiceProviderHash == "" {
-				return ErrArgsRequired("serviceProviderHash")
-			}
-			break
-		default:
-			return ErrUnknownArgs
-		}
-	}
-	if len(createLotteryRequest.Prizes) == 0 {
-		return ErrArgsRequired("prizes")
-	}
-	for _, prize := range createLotteryRequest.Prizes {
-		if prize.WinnerNum <= 0 {
-			return ErrArgsRequired("winnerNum")
-		}
-	}
-
-	// make event object
-	txTimestamp, err := stubInterface.GetTxTimestamp()
-	if err != nil {
-		logger.Error(err)
-		return shim.Error(err.Error())
-	}
-
-	txInfo := Transaction{
-		ID:               stubInterface.GetTxID(),
-		SubmitterID:      createLotteryRequest.SubmitterID,
-		SubmitterAddress: createLotteryRequest.SubmitterAddress,
-		Timestamp:        txTimestamp.Seconds,
-	}
-	stubInterface.GetTxTimestamp()
-	event := NewEvent(createLotteryRequest, txInfo)
-
-	err = event.SaveToLedger(stubInterface)
-	if err != nil {
-		logger.Error(err)
-		return shim.Error(err.Error())
-	}
-
-	responseData, err := json.Marshal(event)
-	if err != nil {
-		logger.Error(err)
-		return shim.Error(err.Error())
-	}
-
-	return shim.Success(responseData)
+	return contractResponse(lotteryContract.CreateLotteryEvent(newTransactionContext(stubInterface), *request))
 }
 
 // args[0] = function name
@@ -158,6 +142,7 @@ func (l *LotteryChaincode) queryLotteryEvent(stubInterface shim.ChaincodeStubInt
 		return ErrArgsUnmarshal
 	}
 
+	ctx := newTransactionContext(stubInterface)
 	switch queryLotteryRequest.QueryType {
 	case QUERY_BY_EVENT_ID:
 		queryByEventIDRequest := &QueryLotteryByEventIDRequest{}
@@ -166,17 +151,7 @@ func (l *LotteryChaincode) queryLotteryEvent(stubInterface shim.ChaincodeStubInt
 			return ErrArgsUnmarshal
 		}
 
-		event, err := LoadEventByUUID(stubInterface, queryByEventIDRequest.EventUUID)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-
-		responseData, err := json.Marshal(event)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-
-		return shim.Success(responseData)
+		return contractResponse(lotteryContract.QueryLotteryEventByID(ctx, *queryByEventIDRequest))
 
 	case QUERY_BY_DATE_RANGE:
 		queryByDateRangeRequest := &QueryLotteryByDateRangeRequest{}
@@ -186,152 +161,518 @@ func (l *LotteryChaincode) queryLotteryEvent(stubInterface shim.ChaincodeStubInt
 			return ErrArgsUnmarshal
 		}
 
-		events, err := LoadEventByDateRange(
-			stubInterface,
-			queryByDateRangeRequest.StartDateTimestamp,
-			queryByDateRangeRequest.EndDateTimestamp,
-		)
+		return contractResponse(lotteryContract.QueryLotteryEventsByDateRange(ctx, *queryByDateRangeRequest))
 
+	case QUERY_ROUNDS_BY_EVENT_ID:
+		queryRoundsRequest := &QueryLotteryRoundsRequest{}
+		err := json.Unmarshal([]byte(args[1]), queryRoundsRequest)
 		if err != nil {
-			logger.Error(err)
-			return shim.Error(err.Error())
+			return ErrArgsUnmarshal
 		}
 
-		responseData, err := json.Marshal(events)
+		return contractResponse(lotteryContract.QueryLotteryRounds(ctx, *queryRoundsRequest))
+
+	case QUERY_BY_FILTER:
+		searchLotteryRequest := &SearchLotteryRequest{}
+		err := json.Unmarshal([]byte(args[1]), searchLotteryRequest)
 		if err != nil {
-			return shim.Error(err.Error())
+			return ErrArgsUnmarshal
 		}
-		return shim.Success(responseData)
+
+		return contractResponse(lotteryContract.SearchLotteryEvents(ctx, *searchLotteryRequest))
 
 	case QUERY_BY_PARTICIPANT_ID:
-	default:
-		return ErrUnknownArgs
+		queryByParticipantIDRequest := &QueryLotteryByParticipantIDRequest{}
+		err := json.Unmarshal([]byte(args[1]), queryByParticipantIDRequest)
+		if err != nil {
+			return ErrArgsUnmarshal
+		}
+
+		return contractResponse(lotteryContract.QueryLotteryEventsByParticipant(ctx, *queryByParticipantIDRequest))
+
 	}
 
 	return ErrUnknownArgs
 }
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) queryLotteryHistory(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &QueryLotteryHistoryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.QueryLotteryHistory(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) queryParticipantPrivateData(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &QueryParticipantPrivateDataRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.QueryParticipantPrivateData(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
 func (l *LotteryChaincode) participateLotteryEvent(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) != 2 {
 		return ErrArgsNum
 	}
 
 	// unmarshal request args
-	participateLotteryRequest := &ParticipateLotteryRequest{}
-	err := json.Unmarshal([]byte(args[1]), participateLotteryRequest)
+	request := &ParticipateLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
 		return ErrArgsUnmarshal
 	}
 
-	event, err := LoadEventByUUID(stubInterface, participateLotteryRequest.EventUUID)
+	return contractResponse(lotteryContract.ParticipateLotteryEvent(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) drawLotteryEvent(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &DrawLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
-	if event.UUID == "" {
-		return shim.Error("cannot find event, ID :" + participateLotteryRequest.EventUUID)
+
+	return contractResponse(lotteryContract.DrawLotteryEvent(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) beginDraw(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	txTimestamp, err := stubInterface.GetTxTimestamp()
+	// unmarshal request args
+	request := &DrawLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
-	participateLotteryRequest.Participant.ParticipateTx = Transaction{
-		ID:               stubInterface.GetTxID(),
-		SubmitterID:      participateLotteryRequest.SubmitterID,
-		SubmitterAddress: participateLotteryRequest.SubmitterAddress,
-		Timestamp:        txTimestamp.Seconds,
+
+	return contractResponse(lotteryContract.BeginDraw(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) continueDraw(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	err = event.Participate(participateLotteryRequest.Participant, txTimestamp.Seconds)
+	// unmarshal request args
+	request := &ContinueDrawRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.ContinueDraw(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) finalizeDraw(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	err = event.SaveToLedger(stubInterface)
+	// unmarshal request args
+	request := &FinalizeDrawRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.FinalizeDraw(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) bulkParticipate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	responseData, err := json.Marshal(event)
+	// unmarshal request args
+	request := &BulkParticipateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
 
-	return shim.Success(responseData)
+	return contractResponse(lotteryContract.BulkParticipate(newTransactionContext(stubInterface), *request))
 }
 
 // args[0] = function name
 // args[1] = json args
-func (l *LotteryChaincode) drawLotteryEvent(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+func (l *LotteryChaincode) uploadBulkChunk(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
 	if len(args) != 2 {
 		return ErrArgsNum
 	}
 
 	// unmarshal request args
-	drawLotteryRequest := &DrawLotteryRequest{}
-	err := json.Unmarshal([]byte(args[1]), drawLotteryRequest)
+	request := &UploadBulkChunkRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
 		return ErrArgsUnmarshal
 	}
 
-	event, err := LoadEventByUUID(stubInterface, drawLotteryRequest.EventUUID)
+	return contractResponse(lotteryContract.UploadBulkChunk(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) setLotteryConfig(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &SetLotteryConfigRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.SetLotteryConfig(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) withdrawParticipation(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
-	if event.UUID == "" {
-		return shim.Error("cannot find event, ID :" + drawLotteryRequest.EventUUID)
+
+	// unmarshal request args
+	request := &WithdrawParticipationRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
 	}
 
-	// check required input seed
-	for _, drawType := range event.DrawTypes {
-		switch drawType {
-		case DRAW_BLOCK_HASH:
-			if drawLotteryRequest.TargetBlock.Hash == "" {
-				return ErrArgsRequired("blockHash")
-			}
-			event.TargetBlock.Hash = drawLotteryRequest.TargetBlock.Hash
-			event.TargetBlock.Timestamp = drawLotteryRequest.TargetBlock.Timestamp
-		case DRAW_SERVICE_PROVIDER_HASH:
-			if drawLotteryRequest.ServiceProviderHash == "" {
-				return ErrArgsRequired("serviceProviderHash")
-			}
-			event.ServiceProviderHash = drawLotteryRequest.ServiceProviderHash
-		}
+	return contractResponse(lotteryContract.WithdrawParticipation(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) removeLotteryEvent(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &RemoveLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.RemoveLotteryEvent(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) updateAllowlist(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &UpdateAllowlistRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.UpdateAllowlist(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) eraseParticipantData(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &EraseParticipantDataRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.EraseParticipantData(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) disqualifyWinner(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &DisqualifyWinnerRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.DisqualifyWinner(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) addLotteryRound(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &AddLotteryRoundRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.AddLotteryRound(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) drawLotteryRound(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	txTimestamp, err := stubInterface.GetTxTimestamp()
+	// unmarshal request args
+	request := &DrawLotteryRoundRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
 
-	txInfo := Transaction{
-		ID:               stubInterface.GetTxID(),
-		SubmitterID:      drawLotteryRequest.SubmitterID,
-		SubmitterAddress: drawLotteryRequest.SubmitterAddress,
-		Timestamp:        txTimestamp.Seconds,
+	return contractResponse(lotteryContract.DrawLotteryRound(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) verifyLotteryEvent(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	err = event.Draw(txInfo)
+	// unmarshal request args
+	request := &VerifyLotteryRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.VerifyLotteryEvent(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) verifyLotteryRound(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
 	}
 
-	event.DrawTx = txInfo
-	err = event.SaveToLedger(stubInterface)
+	// unmarshal request args
+	request := &VerifyLotteryRoundRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
 
-	responseData, err := json.Marshal(event)
+	return contractResponse(lotteryContract.VerifyLotteryRound(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) getDrawCertificate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &GetDrawCertificateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ErrArgsUnmarshal
 	}
 
-	return shim.Success(responseData)
+	return contractResponse(lotteryContract.GetDrawCertificate(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) claimPrize(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &ClaimPrizeRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.ClaimPrize(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) closeClaims(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &CloseClaimsRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.CloseClaims(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) createLotteryTemplate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &CreateLotteryTemplateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.CreateLotteryTemplate(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) updateLotteryTemplate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &UpdateLotteryTemplateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.UpdateLotteryTemplate(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) queryLotteryTemplate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &QueryLotteryTemplateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.QueryLotteryTemplate(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) spawnFromTemplate(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &SpawnFromTemplateRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.SpawnFromTemplate(newTransactionContext(stubInterface), *request))
+}
+
+// args[0] = function name
+// args[1] = json args
+func (l *LotteryChaincode) migrateEvents(stubInterface shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return ErrArgsNum
+	}
+
+	// unmarshal request args
+	request := &MigrateEventsRequest{}
+	err := json.Unmarshal([]byte(args[1]), request)
+	if err != nil {
+		return ErrArgsUnmarshal
+	}
+
+	return contractResponse(lotteryContract.MigrateEvents(newTransactionContext(stubInterface), *request))
 }
 
 func main() {
-	err := shim.Start(new(LotteryChaincode))
+	chaincode := new(LotteryChaincode)
+	if IsServerMode() {
+		server, err := NewChaincodeServer(chaincode)
+		if err != nil {
+			logger.Error("Error configuring Chaincode server: ", err)
+			os.Exit(1)
+		}
+		logger.Info("Starting Chaincode server on ", server.Address)
+		err = server.Start()
+		if err != nil {
+			logger.Error("Error starting Chaincode server: ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	err := shim.Start(chaincode)
 	if err != nil {
-		logger.Info("Error starting Chaincode: %s", err)
+		logger.Error("Error starting Chaincode: ", err)
 	}
 }
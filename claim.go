@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+)
+
+type ClaimStatus string
+
+const (
+	CLAIM_PENDING ClaimStatus = "PENDING_CLAIM"
+	CLAIM_CLAIMED ClaimStatus = "CLAIMED"
+	CLAIM_EXPIRED ClaimStatus = "EXPIRED"
+)
+
+// Claim tracks whether a winner has collected the prize.
+type Claim struct {
+	ParticipantUUID string      `json:"participantUUID"`
+	Status          ClaimStatus `json:"status" metadata:",optional"`
+	Deadline        int64       `json:"deadline" metadata:",optional"` // UNIX timestamp, 0 means no deadline
+	StatusTx        Transaction `json:"statusTx" metadata:",optional"` // transaction which made the current status
+}
+
+// openClaims makes a pending claim for every winner of the prize.
+func (p *Prize) openClaims(drawTx Transaction) {
+	p.Claims = make([]Claim, 0)
+	for _, winner := range p.Winners {
+		p.Claims = append(p.Claims, Claim{
+			ParticipantUUID: winner.UUID,
+			Status:          CLAIM_PENDING,
+			Deadline:        p.ClaimDeadline,
+			StatusTx:        drawTx,
+		})
+	}
+}
+
+// newPendingClaim makes a claim for a promoted alternate.
+// if the prize deadline is already passed, the alternate gets the same claim window the original winners had.
+func (p *Prize) newPendingClaim(participantUUID string, drawTx Transaction, tx Transaction) Claim {
+	deadline := p.ClaimDeadline
+	if deadline != 0 && deadline < tx.Timestamp {
+		deadline = tx.Timestamp + (p.ClaimDeadline - drawTx.Timestamp)
+	}
+	return Claim{
+		ParticipantUUID: participantUUID,
+		Status:          CLAIM_PENDING,
+		Deadline:        deadline,
+		StatusTx:        tx,
+	}
+}
+
+func (p *Prize) findClaim(participantUUID string) *Claim {
+	for idx := range p.Claims {
+		if p.Claims[idx].ParticipantUUID == participantUUID {
+			return &p.Claims[idx]
+		}
+	}
+	return nil
+}
+
+func (p *Prize) removeClaim(participantUUID string) {
+	for idx := range p.Claims {
+		if p.Claims[idx].ParticipantUUID == participantUUID {
+			p.Claims = append(p.Claims[:idx], p.Claims[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (p *Prize) findWinner(participantUUID string) *Participant {
+	for idx := range p.Winners {
+		if p.Winners[idx].UUID == participantUUID {
+			return &p.Winners[idx]
+		}
+	}
+	return nil
+}
+
+// ClaimPrize marks the winner's claim as claimed.
+// the winner proves the identity by the certificate used at participation,
+// or by a signature made with the key registered in AuthInformation of the participant private data.
+func (e *Event) ClaimPrize(prizeUUID string, participantUUID string, signature string, authInformation string, tx Transaction) error {
+	if e.Status != STATUS_DRAWN {
+		return ErrInvalidStatus.WithDetail("status is not drawn")
+	}
+
+	prize := e.findPrize(prizeUUID)
+	if prize == nil {
+		return ErrPrizeNotFound.WithDetail(prizeUUID)
+	}
+
+	winner := prize.findWinner(participantUUID)
+	claim := prize.findClaim(participantUUID)
+	if winner == nil || claim == nil {
+		return ErrNotWinner
+	}
+
+	if claim.Status != CLAIM_PENDING {
+		return ErrClaimNotPending.WithDetail(string(claim.Status))
+	}
+	if claim.Deadline != 0 && tx.Timestamp > claim.Deadline {
+		return ErrClaimDeadlinePassed
+	}
+
+	// participants joined before PII was moved to the private data collection
+	if authInformation == "" {
+		authInformation = winner.AuthInformation
+	}
+
+	sameClient := tx.ClientID != "" && tx.ClientID == winner.ParticipateTx.ClientID
+	if !sameClient && !VerifyClaimSignature(authInformation, MakeClaimMessage(e.UUID, prizeUUID, participantUUID), signature) {
+		return ErrUnauthorized.WithDetail("cannot authenticate winner")
+	}
+
+	claim.Status = CLAIM_CLAIMED
+	claim.StatusTx = tx
+	return nil
+}
+
+// CloseClaims expires every pending claim whose deadline is passed.
+func (e *Event) CloseClaims(tx Transaction) error {
+	if e.Status != STATUS_DRAWN {
+		return ErrInvalidStatus.WithDetail("status is not drawn")
+	}
+
+	for prizeIdx := range e.Prizes {
+		for claimIdx := range e.Prizes[prizeIdx].Claims {
+			claim := &e.Prizes[prizeIdx].Claims[claimIdx]
+			if claim.Status == CLAIM_PENDING && claim.Deadline != 0 && tx.Timestamp > claim.Deadline {
+				claim.Status = CLAIM_EXPIRED
+				claim.StatusTx = tx
+			}
+		}
+	}
+	return nil
+}
+
+// MakeClaimMessage returns the message a winner signs to claim the prize.
+func MakeClaimMessage(eventUUID string, prizeUUID string, participantUUID string) []byte {
+	return []byte(eventUUID + "_" + prizeUUID + "_" + participantUUID)
+}
+
+// VerifyClaimSignature checks base64 encoded ASN.1 ECDSA signature of the sha256 hashed message
+// against the PEM encoded public key registered in AuthInformation.
+func VerifyClaimSignature(authInformation string, message []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	block, _ := pem.Decode([]byte(authInformation))
+	if block == nil {
+		return false
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return false
+	}
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	parsed := struct{ R, S *big.Int }{}
+	if _, err = asn1.Unmarshal(sig, &parsed); err != nil {
+		return false
+	}
+	hash := sha256.Sum256(message)
+	return ecdsa.Verify(ecdsaPublicKey, hash[:], parsed.R, parsed.S)
+}
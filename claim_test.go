@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+)
+
+func TestEventClaimPrizeWithSignature(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authInformation := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+
+	event := newDrawTestEvent(10)
+	event.Prizes[0].ClaimDeadline = 300
+	err = event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	winner := event.Prizes[0].Winners[0]
+	hash := sha256.Sum256(MakeClaimMessage(event.UUID, "prize1", winner.UUID))
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = event.ClaimPrize("prize1", winner.UUID, "invalid", authInformation, Transaction{ID: "claimTx", Timestamp: 250})
+	if err == nil {
+		t.Error("claim with invalid signature must fail")
+	}
+
+	err = event.ClaimPrize("prize1", winner.UUID, base64.StdEncoding.EncodeToString(sig), authInformation, Transaction{ID: "claimTx", Timestamp: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Prizes[0].Claims[0].Status != CLAIM_CLAIMED {
+		t.Errorf("expected %s, got %s", CLAIM_CLAIMED, event.Prizes[0].Claims[0].Status)
+	}
+}
+
+func TestEventClaimPrizeWithCertificate(t *testing.T) {
+	event := newDrawTestEvent(10)
+	for idx := range event.Participants {
+		event.Participants[idx].ParticipateTx.ClientID = "client" + event.Participants[idx].UUID
+	}
+	err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	winner := event.Prizes[1].Winners[0]
+	err = event.ClaimPrize("prize2", winner.UUID, "", "", Transaction{ID: "claimTx", Timestamp: 250, ClientID: "otherClient"})
+	if err == nil {
+		t.Error("claim from other client must fail")
+	}
+
+	err = event.ClaimPrize("prize2", winner.UUID, "", "", Transaction{ID: "claimTx", Timestamp: 250, ClientID: winner.ParticipateTx.ClientID})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventCloseClaims(t *testing.T) {
+	event := newDrawTestEvent(10)
+	event.Prizes[0].ClaimDeadline = 300
+	drawTx := Transaction{ID: "drawTx", Timestamp: 200}
+	err := event.Draw(drawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.DrawTx = drawTx
+
+	err = event.CloseClaims(Transaction{ID: "closeTx", Timestamp: 301})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Prizes[0].Claims[0].Status != CLAIM_EXPIRED {
+		t.Errorf("expected %s, got %s", CLAIM_EXPIRED, event.Prizes[0].Claims[0].Status)
+	}
+	// prize without deadline never expires
+	for _, claim := range event.Prizes[1].Claims {
+		if claim.Status != CLAIM_PENDING {
+			t.Errorf("expected %s, got %s", CLAIM_PENDING, claim.Status)
+		}
+	}
+
+	// promoted alternate gets the same claim window
+	expired := event.Prizes[0].Winners[0]
+	err = event.DisqualifyWinner("prize1", expired.UUID, "never claimed", Transaction{ID: "disqualifyTx", Timestamp: 400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim := event.Prizes[0].findClaim(event.Prizes[0].Winners[0].UUID)
+	if claim == nil || claim.Status != CLAIM_PENDING || claim.Deadline != 500 {
+		t.Errorf("unexpected promoted claim : %+v", claim)
+	}
+}
+
+func TestCloseClaimsAuthorization(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	event := saveDrawnEvent(t, m)
+	request := CloseClaimsRequest{EventUUID: event.UUID}
+
+	m.setClient(t, event.Prizes[0].Winners[0].UUID)
+	if _, ok := m.call(t, "closeTx", "closeClaims", request); ok {
+		t.Fatal("participant closed the claims")
+	}
+	m.setClient(t, "provider")
+	if _, ok := m.call(t, "closeTx", "closeClaims", request); !ok {
+		t.Fatal("event creator is rejected")
+	}
+}
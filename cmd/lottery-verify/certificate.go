@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+	"github.com/sslab-archive/block_lottery_cc/merkle"
+)
+
+// ReadCertificate reads the signed certificate JSON, with or without the response envelope.
+func ReadCertificate(r io.Reader) (*draw.SignedCertificate, error) {
+	envelope := struct {
+		SchemaVersion string          `json:"schemaVersion"`
+		Data          json.RawMessage `json:"data"`
+	}{}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.SchemaVersion != "" && len(envelope.Data) > 0 {
+		b = envelope.Data
+	}
+
+	signed := &draw.SignedCertificate{}
+	if err = json.Unmarshal(b, signed); err != nil {
+		return nil, err
+	}
+	if signed.Certificate.EventUUID == "" {
+		return nil, errors.New("certificate is not in the file")
+	}
+	return signed, nil
+}
+
+// VerifyCertificate checks the signature and the inputs of the certificate, and re-derives the winners.
+// issuerPEM pins the signer, the embedded signer is trusted if it is empty.
+func VerifyCertificate(signed *draw.SignedCertificate, issuerPEM string, inputHash string) *Report {
+	certificate := &signed.Certificate
+	report := &Report{EventUUID: certificate.EventUUID}
+
+	if mismatch := verifySigner(signed, issuerPEM); mismatch != "" {
+		report.fail("signature", mismatch)
+		return report
+	}
+	if signed.Signature == "" {
+		report.skip("signature", "certificate is not signed, keep the endorsement of the query as the proof")
+	} else {
+		report.pass("signature")
+	}
+
+	if certificate.Version != draw.CERTIFICATE_VERSION {
+		report.fail("version", "certificate version "+certificate.Version+" is not supported")
+		return report
+	}
+	if certificate.Algorithms != draw.CurrentAlgorithms() {
+		report.fail("algorithms", "algorithms of the certificate are not supported by this verifier")
+		return report
+	}
+	report.pass("algorithms")
+
+	if root := merkle.RootHex(certificate.ParticipantLeaves()); root != certificate.ParticipantRoot {
+		report.fail("participant root", "participant root is "+certificate.ParticipantRoot+", expected "+root)
+		return report
+	}
+	report.pass("participant root")
+
+	if certificate.Seed.BlockHeader == "" {
+		report.skip("block header", "block header is not recorded")
+	} else if mismatch := verifyCertificateHeader(certificate.Seed); mismatch != "" {
+		report.fail("block header", mismatch)
+		return report
+	} else {
+		report.pass("block header")
+	}
+
+	drawReport := Verify(certificateEvent(certificate), inputHash, nil)
+	report.Checks = append(report.Checks, drawReport.Checks...)
+	return report
+}
+
+func verifySigner(signed *draw.SignedCertificate, issuerPEM string) string {
+	if signed.Signature == "" {
+		if issuerPEM != "" {
+			return "certificate is not signed by the issuer"
+		}
+		return ""
+	}
+	if err := signed.VerifySignature(); err != nil {
+		return err.Error()
+	}
+	if issuerPEM == "" {
+		return ""
+	}
+
+	issuerKey, err := draw.ParseSignerPublicKey(issuerPEM)
+	if err != nil {
+		return "issuer : " + err.Error()
+	}
+	signerKey, _ := draw.ParseSignerPublicKey(signed.Signer)
+	if issuerKey.X.Cmp(signerKey.X) != 0 || issuerKey.Y.Cmp(signerKey.Y) != 0 {
+		return "certificate is not signed by the issuer"
+	}
+	return ""
+}
+
+func verifyCertificateHeader(seed draw.CertificateSeed) string {
+	if seed.BlockType != BITCOIN {
+		return "only bitcoin headers are verified"
+	}
+	header, err := draw.ParseBitcoinHeader(seed.BlockHeader)
+	if err != nil {
+		return err.Error()
+	}
+	if header.Hash != seed.BlockHash {
+		return "block hash is " + seed.BlockHash + ", header hash is " + header.Hash
+	}
+	if err = header.CheckProofOfWork(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// certificateEvent returns the drawn event of the certificate for Verify.
+func certificateEvent(certificate *draw.Certificate) *Event {
+	event := &Event{
+		UUID:                certificate.EventUUID,
+		Status:              STATUS_DRAWN,
+		MaxParticipant:      certificate.MaxParticipant,
+		DrawTypes:           certificate.DrawTypes,
+		TargetBlock:         BlockInfo{BlockType: certificate.Seed.BlockType, Hash: certificate.Seed.BlockHash, Height: certificate.Seed.BlockHeight},
+		ServiceProviderHash: certificate.Seed.ServiceProviderHash,
+		SeedHash:            certificate.Seed.SeedHash,
+	}
+	for _, participant := range certificate.Participants {
+		event.Participants = append(event.Participants, Member{UUID: participant.UUID})
+	}
+	for _, prize := range certificate.Prizes {
+		eventPrize := Prize{
+			UUID:         prize.UUID,
+			WinnerNum:    prize.WinnerNum,
+			AlternateNum: prize.AlternateNum,
+			Winners:      members(prize.Winners),
+			Alternates:   members(prize.Alternates),
+		}
+		for _, uuid := range prize.Disqualified {
+			eventPrize.Disqualifications = append(eventPrize.Disqualifications, Disqualification{Participant: Member{UUID: uuid}})
+		}
+		event.Prizes = append(event.Prizes, eventPrize)
+	}
+	return event
+}
+
+func members(uuids []string) []Member {
+	result := make([]Member, len(uuids))
+	for idx, uuid := range uuids {
+		result[idx] = Member{UUID: uuid}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+func readTestCertificate(t *testing.T) (*draw.SignedCertificate, string) {
+	certificateFile, err := os.Open("testdata/certificate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer certificateFile.Close()
+	signed, err := ReadCertificate(certificateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuerPEM, err := ioutil.ReadFile("testdata/issuer.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed, string(issuerPEM)
+}
+
+func TestVerifyCertificate(t *testing.T) {
+	signed, issuerPEM := readTestCertificate(t)
+
+	report := VerifyCertificate(signed, issuerPEM, "")
+	if !report.Passed() {
+		t.Fatal(report.String())
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherPublicKey, _ := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	otherIssuer := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: otherPublicKey}))
+	if report = VerifyCertificate(signed, otherIssuer, ""); report.Passed() {
+		t.Error("certificate must be pinned to the issuer")
+	}
+
+	signed.Certificate.Prizes[0].Winners[0] = "participant9"
+	report = VerifyCertificate(signed, "", "")
+	if report.Passed() || !strings.Contains(report.String(), "FAIL signature") {
+		t.Errorf("modified certificate must fail the signature : %s", report.String())
+	}
+
+	// the draw is still checked when the certificate is not signed
+	signed.Signature = ""
+	report = VerifyCertificate(signed, "", "")
+	if report.Passed() || !strings.Contains(report.String(), "SKIP signature") || !strings.Contains(report.String(), "winners : position 0") {
+		t.Errorf("modified winners must fail : %s", report.String())
+	}
+}
+
+func TestVerifyCertificateInputs(t *testing.T) {
+	signed, _ := readTestCertificate(t)
+	signed.Signature = ""
+
+	signed.Certificate.Participants[0].Commitment = "forged"
+	report := VerifyCertificate(signed, "", "")
+	if report.Passed() || !strings.Contains(report.String(), "FAIL participant root") {
+		t.Errorf("forged commitment must fail : %s", report.String())
+	}
+
+	signed, _ = readTestCertificate(t)
+	signed.Signature = ""
+	signed.Certificate.Seed.BlockHash = strings.Repeat("0", 64)
+	report = VerifyCertificate(signed, "", "")
+	if report.Passed() || !strings.Contains(report.String(), "FAIL block header") {
+		t.Errorf("block hash out of the header must fail : %s", report.String())
+	}
+}
@@ -0,0 +1,98 @@
+// Command lottery-verify checks the draw of an exported lottery event or a draw certificate without ledger access.
+//
+//	lottery-verify -event event.json [-headers headers.txt] [-input-hash {seed hash}]
+//	lottery-verify -certificate certificate.json [-issuer issuer.pem] [-input-hash {seed hash}]
+//
+// the event file is the response of queryLotteryEvent, and the certificate file is the response of getDrawCertificate.
+// the header file has "{height} {hex header}" lines.
+// it exits with 1 when the draw is not verified, and 2 when the files cannot be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+func main() {
+	eventPath := flag.String("event", "", "exported event JSON file")
+	certificatePath := flag.String("certificate", "", "draw certificate JSON file")
+	headersPath := flag.String("headers", "", "bitcoin block headers file, optional")
+	issuerPath := flag.String("issuer", "", "PEM certificate or public key of the certificate issuer, optional")
+	inputHash := flag.String("input-hash", "", "expected seed hash, optional")
+	flag.Parse()
+
+	var report *Report
+	switch {
+	case *eventPath != "" && *certificatePath == "":
+		report = verifyEventFile(*eventPath, *headersPath, *inputHash)
+	case *certificatePath != "" && *eventPath == "":
+		report = verifyCertificateFile(*certificatePath, *issuerPath, *inputHash)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Print(report.String())
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+func verifyEventFile(eventPath string, headersPath string, inputHash string) *Report {
+	eventFile, err := os.Open(eventPath)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer eventFile.Close()
+	event, err := ReadEvent(eventFile)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var headers map[int64]*draw.BitcoinHeader
+	if headersPath != "" {
+		headersFile, err := os.Open(headersPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		defer headersFile.Close()
+		headers, err = ReadBitcoinHeaders(headersFile)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	return Verify(event, inputHash, headers)
+}
+
+func verifyCertificateFile(certificatePath string, issuerPath string, inputHash string) *Report {
+	certificateFile, err := os.Open(certificatePath)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer certificateFile.Close()
+	signed, err := ReadCertificate(certificateFile)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	issuerPEM := ""
+	if issuerPath != "" {
+		b, err := ioutil.ReadFile(issuerPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		issuerPEM = string(b)
+	}
+
+	return VerifyCertificate(signed, issuerPEM, inputHash)
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, "lottery-verify : "+err.Error())
+	os.Exit(2)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+// the exported event, as queryLotteryEvent returns. only the fields of the draw are read.
+type Event struct {
+	UUID                string    `json:"UUID"`
+	Status              string    `json:"status"`
+	MaxParticipant      int64     `json:"maxParticipant"`
+	Participants        []Member  `json:"participants"`
+	DrawTypes           []string  `json:"drawTypes"`
+	Prizes              []Prize   `json:"prizes"`
+	TargetBlock         BlockInfo `json:"targetBlock"`
+	ServiceProviderHash string    `json:"serviceProviderHash"`
+	SeedHash            string    `json:"seedHash"`
+}
+
+type Member struct {
+	UUID      string `json:"UUID"`
+	Withdrawn bool   `json:"withdrawn"` // withdrawn participants are not drawn
+}
+
+type Prize struct {
+	UUID              string             `json:"UUID"`
+	WinnerNum         int64              `json:"winnerNum"`
+	AlternateNum      int64              `json:"alternateNum"`
+	Winners           []Member           `json:"winners"`
+	Alternates        []Member           `json:"alternates"`
+	Disqualifications []Disqualification `json:"disqualifications"`
+}
+
+type Disqualification struct {
+	Participant Member `json:"participant"`
+}
+
+type BlockInfo struct {
+	BlockType string `json:"blockType"`
+	Hash      string `json:"hash"`
+	Height    int64  `json:"height"`
+}
+
+const (
+	STATUS_DRAWN    = "DRAWN"
+	DRAW_BLOCK_HASH = "DRAW_BLOCK_HASH"
+	BITCOIN         = "BITCOIN"
+)
+
+// Check is a line of the report.
+type Check struct {
+	Name     string
+	Passed   bool
+	Skipped  bool // not checked, Mismatch is the reason
+	Mismatch string
+}
+
+// Report lists the checks until the first mismatch.
+type Report struct {
+	EventUUID string
+	Checks    []Check
+}
+
+func (r *Report) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) pass(name string) {
+	r.Checks = append(r.Checks, Check{Name: name, Passed: true})
+}
+
+func (r *Report) skip(name string, reason string) {
+	r.Checks = append(r.Checks, Check{Name: name, Passed: true, Skipped: true, Mismatch: reason})
+}
+
+func (r *Report) fail(name string, mismatch string) {
+	r.Checks = append(r.Checks, Check{Name: name, Mismatch: mismatch})
+}
+
+func (r *Report) String() string {
+	lines := []string{"event " + r.EventUUID}
+	for _, check := range r.Checks {
+		if check.Skipped {
+			lines = append(lines, "SKIP "+check.Name+" : "+check.Mismatch)
+		} else if check.Passed {
+			lines = append(lines, "PASS "+check.Name)
+		} else {
+			lines = append(lines, "FAIL "+check.Name+" : "+check.Mismatch)
+		}
+	}
+	if r.Passed() {
+		lines = append(lines, "RESULT PASS")
+	} else {
+		lines = append(lines, "RESULT FAIL")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ReadEvent reads the event JSON, with or without the response envelope.
+func ReadEvent(r io.Reader) (*Event, error) {
+	// events have a numeric schemaVersion of the record, the envelope has the string version of the response
+	envelope := struct {
+		SchemaVersion json.RawMessage `json:"schemaVersion"`
+		Data          json.RawMessage `json:"data"`
+	}{}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &envelope); err != nil {
+		return nil, err
+	}
+	if len(envelope.SchemaVersion) > 0 && envelope.SchemaVersion[0] == '"' && len(envelope.Data) > 0 {
+		b = envelope.Data
+	}
+
+	event := &Event{}
+	if err = json.Unmarshal(b, event); err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, errors.New("event UUID is not in the file")
+	}
+	return event, nil
+}
+
+// Verify re-derives the seed and the prize results of the event. headers is nil when block headers are not checked.
+func Verify(event *Event, inputHash string, headers map[int64]*draw.BitcoinHeader) *Report {
+	report := &Report{EventUUID: event.UUID}
+
+	if event.Status != STATUS_DRAWN {
+		report.fail("status", "event is not drawn")
+		return report
+	}
+	report.pass("status")
+
+	if headers != nil {
+		if mismatch := verifyTargetBlock(event, headers); mismatch != "" {
+			report.fail("target block", mismatch)
+			return report
+		}
+		report.pass("target block")
+	}
+
+	seed := draw.MakeSeed(event.TargetBlock.Hash, event.ServiceProviderHash)
+	if seed != event.SeedHash {
+		report.fail("seed", "seed hash is not made from the seed inputs")
+		return report
+	}
+	if inputHash != "" && inputHash != seed {
+		report.fail("seed", "seed hash is not matched with input hash")
+		return report
+	}
+	report.pass("seed")
+
+	participants := make([]Member, 0, len(event.Participants))
+	for _, participant := range event.Participants {
+		if !participant.Withdrawn {
+			participants = append(participants, participant)
+		}
+	}
+	if int64(len(participants)) > event.MaxParticipant {
+		participants = participants[0:event.MaxParticipant]
+	}
+	order := draw.ShuffleIndices(len(participants), seed)
+
+	slots := make([]draw.PrizeSlots, len(event.Prizes))
+	for idx, prize := range event.Prizes {
+		slots[idx] = draw.PrizeSlots{WinnerNum: prize.WinnerNum, AlternateNum: prize.AlternateNum}
+	}
+	assignments := draw.Assign(len(participants), slots)
+
+	uuidAt := func(position int) string {
+		return participants[order[position]].UUID
+	}
+	for idx, prize := range event.Prizes {
+		assignment := &assignments[idx]
+		for _, disqualification := range prize.Disqualifications {
+			position := -1
+			for i, winner := range assignment.Winners {
+				if uuidAt(winner) == disqualification.Participant.UUID {
+					position = i
+					break
+				}
+			}
+			if position < 0 {
+				report.fail("prize "+prize.UUID, "cannot replay disqualification of "+disqualification.Participant.UUID)
+				return report
+			}
+			assignment.Disqualify(position)
+		}
+
+		if mismatch := compareMembers(prize.Winners, assignment.Winners, uuidAt); mismatch != "" {
+			report.fail("prize "+prize.UUID+" winners", mismatch)
+			return report
+		}
+		if mismatch := compareMembers(prize.Alternates, assignment.Alternates, uuidAt); mismatch != "" {
+			report.fail("prize "+prize.UUID+" alternates", mismatch)
+			return report
+		}
+		report.pass("prize " + prize.UUID)
+	}
+	return report
+}
+
+func compareMembers(recorded []Member, positions []int, uuidAt func(int) string) string {
+	if len(recorded) != len(positions) {
+		return "count is " + strconv.Itoa(len(recorded)) + ", expected " + strconv.Itoa(len(positions))
+	}
+	for idx, position := range positions {
+		if recorded[idx].UUID != uuidAt(position) {
+			return "position " + strconv.Itoa(idx) + " is " + recorded[idx].UUID + ", expected " + uuidAt(position)
+		}
+	}
+	return ""
+}
+
+func verifyTargetBlock(event *Event, headers map[int64]*draw.BitcoinHeader) string {
+	usesBlock := false
+	for _, drawType := range event.DrawTypes {
+		usesBlock = usesBlock || drawType == DRAW_BLOCK_HASH
+	}
+	if !usesBlock {
+		return ""
+	}
+	if event.TargetBlock.BlockType != BITCOIN {
+		return "only bitcoin headers are verified"
+	}
+
+	header, exist := headers[event.TargetBlock.Height]
+	if !exist {
+		return "header of height " + strconv.FormatInt(event.TargetBlock.Height, 10) + " is not in the header file"
+	}
+	if header.Hash != event.TargetBlock.Hash {
+		return "target block hash is " + event.TargetBlock.Hash + ", header hash is " + header.Hash
+	}
+	return ""
+}
+
+// ReadBitcoinHeaders reads "{height} {hex header}" lines. every header must meet its proof of work,
+// and headers of consecutive heights must be linked.
+func ReadBitcoinHeaders(r io.Reader) (map[int64]*draw.BitcoinHeader, error) {
+	headers := make(map[int64]*draw.BitcoinHeader)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.New("line " + strconv.Itoa(lineNum) + " : expected {height} {hex header}")
+		}
+		height, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(lineNum) + " : " + err.Error())
+		}
+		header, err := draw.ParseBitcoinHeader(fields[1])
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(lineNum) + " : " + err.Error())
+		}
+		if err = header.CheckProofOfWork(); err != nil {
+			return nil, errors.New("line " + strconv.Itoa(lineNum) + " : " + err.Error())
+		}
+		headers[height] = header
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for height, header := range headers {
+		if previous, exist := headers[height-1]; exist && previous.Hash != header.PrevBlock {
+			return nil, errors.New("header of height " + strconv.FormatInt(height, 10) + " is not linked to the previous header")
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+func readTestData(t *testing.T) (*Event, map[int64]*draw.BitcoinHeader) {
+	eventFile, err := os.Open("testdata/event.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eventFile.Close()
+	event, err := ReadEvent(eventFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headersFile, err := os.Open("testdata/headers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer headersFile.Close()
+	headers, err := ReadBitcoinHeaders(headersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event, headers
+}
+
+func TestVerifyExportedEvent(t *testing.T) {
+	event, headers := readTestData(t)
+
+	report := Verify(event, event.SeedHash, headers)
+	if !report.Passed() {
+		t.Fatal(report.String())
+	}
+
+	event.Prizes[1].Winners[0], event.Prizes[1].Winners[1] = event.Prizes[1].Winners[1], event.Prizes[1].Winners[0]
+	report = Verify(event, "", nil)
+	if report.Passed() || !strings.Contains(report.String(), "FAIL prize "+event.Prizes[1].UUID+" winners : position 0") {
+		t.Errorf("swapped winners must fail : %s", report.String())
+	}
+}
+
+// withdrawn participants are exported with the event, and are not drawn.
+func TestVerifyWithdrawnParticipant(t *testing.T) {
+	event, headers := readTestData(t)
+
+	event.Participants = append([]Member{{UUID: "withdrawn", Withdrawn: true}}, event.Participants...)
+	report := Verify(event, event.SeedHash, headers)
+	if !report.Passed() {
+		t.Fatal(report.String())
+	}
+}
+
+func TestVerifyTargetBlock(t *testing.T) {
+	event, headers := readTestData(t)
+
+	event.TargetBlock.Height = 0
+	report := Verify(event, "", headers)
+	if report.Passed() || report.Checks[len(report.Checks)-1].Name != "target block" {
+		t.Errorf("target block must not match the header : %s", report.String())
+	}
+
+	_, err := ReadBitcoinHeaders(strings.NewReader("0 " + strings.Repeat("00", draw.BITCOIN_HEADER_SIZE)))
+	if err == nil {
+		t.Error("header without proof of work must be rejected")
+	}
+}
+
+func TestReadBareEvent(t *testing.T) {
+	event, err := ReadEvent(strings.NewReader(`{"docType":"event","schemaVersion":1,"UUID":"bu6tq0c3ldf3j0g5cnk0","status":"DRAWN"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.UUID != "bu6tq0c3ldf3j0g5cnk0" || event.Status != STATUS_DRAWN {
+		t.Fatal("bare event is not read")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// LOTTERY_CONFIG_KEY is the key of the lottery configuration in the world state.
+const LOTTERY_CONFIG_KEY = "lotteryConfig"
+
+// defaults of the configuration, used until an administrator records one.
+const (
+	DEFAULT_MAX_BULK_ROWS       = 1000
+	DEFAULT_MAX_BULK_CHUNK_SIZE = 256 * 1024
+	DEFAULT_MAX_BULK_CHUNKS     = 16
+	DEFAULT_MAX_PARTICIPANT     = 100000
+)
+
+// hard limits of the configuration. they bound the world state and the private data a single transaction can write.
+const (
+	MAX_BULK_ROWS       = 100000
+	MAX_BULK_CHUNK_SIZE = 1024 * 1024
+	MAX_BULK_CHUNKS     = 256
+)
+
+// LotteryConfig is the channel wide configuration of the chaincode, changed by administrators.
+type LotteryConfig struct {
+	DocType       DocType `json:"docType"`
+	SchemaVersion int64   `json:"schemaVersion"`
+
+	MaxBulkRows      int64 `json:"maxBulkRows"`      // participants of a bulkParticipate transaction
+	MaxBulkChunkSize int64 `json:"maxBulkChunkSize"` // bytes of an uploaded chunk of a participant file
+	MaxBulkChunks    int64 `json:"maxBulkChunks"`    // chunks of a participant file
+	MaxParticipant   int64 `json:"maxParticipant"`   // participants of an event
+
+	UpdateTx Transaction `json:"updateTx"`
+}
+
+func NewDefaultLotteryConfig() *LotteryConfig {
+	return &LotteryConfig{
+		DocType:          DOC_TYPE_CONFIG,
+		SchemaVersion:    CurrentSchemaVersion(DOC_TYPE_CONFIG),
+		MaxBulkRows:      DEFAULT_MAX_BULK_ROWS,
+		MaxBulkChunkSize: DEFAULT_MAX_BULK_CHUNK_SIZE,
+		MaxBulkChunks:    DEFAULT_MAX_BULK_CHUNKS,
+		MaxParticipant:   DEFAULT_MAX_PARTICIPANT,
+	}
+}
+
+// LoadLotteryConfig loads the recorded configuration, or the default one if none is recorded.
+func LoadLotteryConfig(stubInterface shim.ChaincodeStubInterface) (*LotteryConfig, error) {
+	b, err := stubInterface.GetState(LOTTERY_CONFIG_KEY)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return NewDefaultLotteryConfig(), nil
+	}
+
+	config := &LotteryConfig{}
+	err = UnmarshalRecord(DOC_TYPE_CONFIG, b, config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *LotteryConfig) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
+	c.DocType = DOC_TYPE_CONFIG
+	c.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_CONFIG)
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(LOTTERY_CONFIG_KEY, b)
+}
+
+// CheckMaxParticipant checks the participant limit of an event is within the configured one.
+func (c *LotteryConfig) CheckMaxParticipant(maxParticipant int64) error {
+	if maxParticipant > c.MaxParticipant {
+		return ErrInvalidArg.WithField("maxParticipant").WithDetail("events take at most " + strconv.FormatInt(c.MaxParticipant, 10) + " participants")
+	}
+	return nil
+}
@@ -0,0 +1,1399 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+// LOTTERY_CONTRACT_NAME is the contract namespace, functions are called as {name}:{function} or by the function name only.
+const LOTTERY_CONTRACT_NAME = "LotteryContract"
+
+// LotteryContract exposes the lottery operations as typed transaction functions.
+// the request and response types are described in the contract metadata ( org.hyperledger.fabric:GetMetadata ).
+// errors are returned in the message of the response, prefixed by the stable code of the error catalog.
+type LotteryContract struct {
+	contractapi.Contract
+}
+
+func NewLotteryContract() *LotteryContract {
+	contract := new(LotteryContract)
+	contract.Name = LOTTERY_CONTRACT_NAME
+	contract.Info = metadata.InfoMetadata{
+		Title:       "block lottery",
+		Description: "verifiable lottery on the blockchain",
+		Version:     SCHEMA_VERSION,
+	}
+	return contract
+}
+
+// GetEvaluateTransactions returns the read only transaction functions.
+func (c *LotteryContract) GetEvaluateTransactions() []string {
+	return []string{
+		"QueryLotteryEventByID",
+		"QueryLotteryEventsByDateRange",
+		"QueryLotteryRounds",
+		"SearchLotteryEvents",
+		"QueryLotteryEventsByParticipant",
+		"QueryLotteryHistory",
+		"QueryParticipantPrivateData",
+		"VerifyLotteryEvent",
+		"VerifyLotteryRound",
+		"GetDrawCertificate",
+		"QueryLotteryTemplate",
+	}
+}
+
+// NewLotteryContractChaincode returns the chaincode serving the contract functions.
+func NewLotteryContractChaincode(contract *LotteryContract) (*contractapi.ContractChaincode, error) {
+	chaincode, err := contractapi.NewChaincode(contract)
+	if err != nil {
+		return nil, err
+	}
+	chaincode.TransactionSerializer = new(LotterySerializer)
+	return chaincode, nil
+}
+
+// newTransactionContext makes the transaction context of legacy invocations.
+func newTransactionContext(stubInterface shim.ChaincodeStubInterface) *contractapi.TransactionContext {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stubInterface)
+
+	// nil when the creator is not a readable X.509 identity
+	clientIdentity, err := cid.New(stubInterface)
+	if err == nil {
+		ctx.SetClientIdentity(clientIdentity)
+	}
+	return ctx
+}
+
+func (c *LotteryContract) CreateLotteryEvent(ctx contractapi.TransactionContextInterface, request CreateLotteryRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	// check args is valid
+	if err := checkCreateLotteryArgs(stubInterface, &request); err != nil {
+		return nil, err
+	}
+
+	// make event object
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	event := NewEvent(&request, txInfo)
+
+	err = event.FundEscrow(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// checkCreateLotteryArgs checks an event can be created from the request, the request is validated already.
+func checkCreateLotteryArgs(stubInterface shim.ChaincodeStubInterface, request *CreateLotteryRequest) error {
+	config, err := LoadLotteryConfig(stubInterface)
+	if err != nil {
+		return err
+	}
+	if err := config.CheckMaxParticipant(request.MaxParticipant); err != nil {
+		return err
+	}
+	if err := checkSeedArgs(request.DrawTypes, request.TargetBlock, request.ServiceProviderHash); err != nil {
+		return err
+	}
+	if err := checkPrizeArgs(request.Prizes, request.DeadlineTime); err != nil {
+		return err
+	}
+	if err := checkExclusionArgs(request.ExclusionRules); err != nil {
+		return err
+	}
+	if err := checkEligibilityArgs(request.EligibilityRules); err != nil {
+		return err
+	}
+	if err := checkAllowlistArgs(request.AllowlistRoot, request.AllowlistLeaf); err != nil {
+		return err
+	}
+	return checkEscrowArgs(stubInterface, request)
+}
+
+// checkSeedArgs checks the seed inputs required by draw types are given.
+func checkSeedArgs(drawTypes []DrawType, targetBlock BlockInfo, serviceProviderHash string) error {
+	for _, drawType := range drawTypes {
+		switch drawType {
+		case DRAW_BLOCK_HASH:
+			if targetBlock.Height == 0 {
+				return ErrRequired("targetBlock")
+			}
+		case DRAW_SERVICE_PROVIDER_HASH:
+			if serviceProviderHash == "" {
+				return ErrRequired("serviceProviderHash")
+			}
+		default:
+			return ErrUndefinedArg
+		}
+	}
+	return nil
+}
+
+// checkPrizeArgs checks requested prizes can be drawn after the deadline.
+func checkPrizeArgs(prizes []Prize, deadlineTime int64) error {
+	if len(prizes) == 0 {
+		return ErrRequired("prizes")
+	}
+	for _, prize := range prizes {
+		if prize.WinnerNum <= 0 {
+			return ErrRequired("winnerNum")
+		}
+		if prize.AlternateNum < 0 {
+			return ErrInvalidArg.WithField("alternateNum").WithDetail("alternateNum cannot be negative")
+		}
+		if prize.ClaimDeadline != 0 && prize.ClaimDeadline <= deadlineTime {
+			return ErrInvalidArg.WithField("claimDeadline").WithDetail("claimDeadline must be after deadlineTime")
+		}
+	}
+	return nil
+}
+
+// checkExclusionArgs checks exclusion rules are defined.
+func checkExclusionArgs(rules []ExclusionRule) error {
+	for _, rule := range rules {
+		switch rule.Type {
+		case EXCLUDE_PREVIOUS_WINNERS:
+		case EXCLUDE_COOLDOWN:
+			if rule.PeriodSeconds <= 0 {
+				return ErrRequired("periodSeconds")
+			}
+		default:
+			return ErrUndefinedArg
+		}
+	}
+	return nil
+}
+
+func (c *LotteryContract) QueryLotteryEventByID(ctx contractapi.TransactionContextInterface, request QueryLotteryByEventIDRequest) (*Event, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	return LoadEventByUUID(ctx.GetStub(), request.EventUUID)
+}
+
+func (c *LotteryContract) QueryLotteryEventsByDateRange(ctx contractapi.TransactionContextInterface, request QueryLotteryByDateRangeRequest) (*EventPage, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	eventPage, err := LoadEventByDateRange(
+		ctx.GetStub(),
+		request.StartDateTimestamp,
+		request.EndDateTimestamp,
+		request.PageRequest,
+	)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return eventPage, nil
+}
+
+func (c *LotteryContract) QueryLotteryRounds(ctx contractapi.TransactionContextInterface, request QueryLotteryRoundsRequest) (*RoundPage, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	return LoadRoundPage(ctx.GetStub(), request.EventUUID, request.PageRequest)
+}
+
+func (c *LotteryContract) SearchLotteryEvents(ctx contractapi.TransactionContextInterface, request SearchLotteryRequest) (*EventPage, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	return LoadEventBySearch(ctx.GetStub(), &request)
+}
+
+func (c *LotteryContract) QueryLotteryEventsByParticipant(ctx contractapi.TransactionContextInterface, request QueryLotteryByParticipantIDRequest) (*EventPage, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	if request.ParticipantUUID == "" {
+		return nil, ErrRequired("participantUUID")
+	}
+
+	return LoadEventByParticipantUUID(ctx.GetStub(), request.ParticipantUUID, request.PageRequest)
+}
+
+func (c *LotteryContract) QueryLotteryHistory(ctx contractapi.TransactionContextInterface, request QueryLotteryHistoryRequest) (*EventHistory, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	return LoadEventHistory(stubInterface, event)
+}
+
+// QueryParticipantPrivateData reads the participant PII.
+// only peers of organizations in the private data collection can read the private data
+func (c *LotteryContract) QueryParticipantPrivateData(ctx contractapi.TransactionContextInterface, request QueryParticipantPrivateDataRequest) (*ParticipantPrivateData, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := loadEventForList(stubInterface, request.EventUUID, true)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	privateData, err := LoadParticipantPrivateData(stubInterface, event, request.ParticipantUUID)
+	if err != nil {
+		return nil, err
+	}
+	if privateData == nil {
+		return nil, ErrPrivateDataNotFound.WithDetail(request.ParticipantUUID)
+	}
+
+	return privateData, nil
+}
+
+func (c *LotteryContract) ParticipateLotteryEvent(ctx contractapi.TransactionContextInterface, request ParticipateLotteryRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	request.Participant.ParticipateTx = txInfo
+
+	privateData, err := request.Participant.SeparatePrivateData(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.CheckEligibility(stubInterface, &request.Participant, privateData, txInfo.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.CheckAllowlist(&request.Participant, privateData, request.AllowlistProof)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.CheckExclusionRules(stubInterface, request.Participant.UUID, txInfo.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.Participate(request.Participant, txInfo.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.PayEntryFee(stubInterface, &request.Participant)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	participantKey, err := MakeParticipantKey(stubInterface, event.UUID, request.Participant.UUID)
+	if err != nil {
+		return nil, err
+	}
+	err = privateData.SaveToLedger(stubInterface, event.GetPrivateCollection(), participantKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// DrawLotteryEvent draws the event without loading every participant, the returned event has no participants.
+func (c *LotteryContract) DrawLotteryEvent(ctx contractapi.TransactionContextInterface, request DrawLotteryRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	err = setSeedInputs(event, request)
+	if err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "draw the event"); err != nil {
+		return nil, err
+	}
+
+	err = event.DrawStreaming(stubInterface, txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	event.DrawTx = txInfo
+	err = event.ReleaseEscrow(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// setSeedInputs checks the seed inputs required by the draw types of the event and sets them.
+func setSeedInputs(event *Event, request DrawLotteryRequest) error {
+	for _, drawType := range event.DrawTypes {
+		switch drawType {
+		case DRAW_BLOCK_HASH:
+			if request.TargetBlock.Hash == "" {
+				return ErrRequired("blockHash")
+			}
+			event.TargetBlock.Hash = request.TargetBlock.Hash
+			event.TargetBlock.Timestamp = request.TargetBlock.Timestamp
+			event.TargetBlock.Header = request.TargetBlock.Header
+			if err := event.TargetBlock.CheckHeader(); err != nil {
+				return err
+			}
+		case DRAW_SERVICE_PROVIDER_HASH:
+			if request.ServiceProviderHash == "" {
+				return ErrRequired("serviceProviderHash")
+			}
+			event.ServiceProviderHash = request.ServiceProviderHash
+		}
+	}
+	return nil
+}
+
+// BeginDraw fixes the seed and the participants of the event for a draw over several transactions.
+// ContinueDraw is called until the returned state is READY, then FinalizeDraw writes the winners.
+func (c *LotteryContract) BeginDraw(ctx contractapi.TransactionContextInterface, request DrawLotteryRequest) (*DrawState, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	err = setSeedInputs(event, request)
+	if err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "draw the event"); err != nil {
+		return nil, err
+	}
+
+	state, err := event.BeginDraw(txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	err = state.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// ContinueDraw processes a chunk of the batched draw of the event.
+func (c *LotteryContract) ContinueDraw(ctx contractapi.TransactionContextInterface, request ContinueDrawRequest) (*DrawState, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+	if event.Status != STATUS_DRAWING {
+		return nil, ErrInvalidStatus.WithDetail("status is not drawing. begin the draw first")
+	}
+
+	state, err := LoadDrawState(stubInterface, event.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "draw the event"); err != nil {
+		return nil, err
+	}
+
+	chunkSize := request.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DEFAULT_DRAW_CHUNK_SIZE
+	}
+	err = state.Continue(stubInterface, event, int(chunkSize), txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = state.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// FinalizeDraw writes the winners of a batched draw, the returned event has no participants.
+func (c *LotteryContract) FinalizeDraw(ctx contractapi.TransactionContextInterface, request FinalizeDrawRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	state, err := LoadDrawState(stubInterface, event.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "draw the event"); err != nil {
+		return nil, err
+	}
+
+	err = event.FinalizeDraw(state, txInfo)
+	if err != nil {
+		return nil, err
+	}
+	err = event.ReleaseEscrow(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	err = state.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// BulkParticipate adds a batch of participants in a transaction, only by the creator of the event.
+// the participants are listed in the request without PII, or in a file uploaded with UploadBulkChunk.
+// each row is accepted or rejected on its own, within the rows limit of the lottery configuration.
+func (c *LotteryContract) BulkParticipate(ctx contractapi.TransactionContextInterface, request BulkParticipateRequest) (*BulkParticipateResult, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	config, err := LoadLotteryConfig(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if txInfo.ClientID == "" || txInfo.ClientID != event.EventCreateTx.ClientID {
+		return nil, ErrUnauthorized.WithDetail("only the event creator can add participants in bulk")
+	}
+	// rows pay no fee and have no client to refund or pay, so paid events take participateLotteryEvent only
+	if event.isPaid() {
+		return nil, ErrInvalidStatus.WithDetail("paid events take participants by participateLotteryEvent only")
+	}
+	if event.hasIdentityRules() {
+		return nil, ErrInvalidStatus.WithDetail("eligibility rules on the client identity cannot check rows of bulkParticipate")
+	}
+
+	// rows in args are recorded in the block, the PII is sent in the uploaded file
+	for idx := range request.Participants {
+		if err := request.Participants[idx].checkNoPrivateData("participants["+strconv.Itoa(idx)+"].", TRANSIENT_BULK_CHUNK_KEY); err != nil {
+			return nil, err
+		}
+	}
+
+	participants := request.Participants
+	if request.FileHash != "" {
+		participants, err = LoadBulkFile(stubInterface, event, request.FileHash, request.ChunkNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(participants)) > config.MaxBulkRows {
+		return nil, ErrInvalidArg.WithField("participants").WithDetail("a batch has at most " + strconv.FormatInt(config.MaxBulkRows, 10) + " participants")
+	}
+	if request.FileHash != "" {
+		err = RemoveBulkFile(stubInterface, event, request.FileHash, request.ChunkNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return BulkParticipate(stubInterface, event, participants, txInfo)
+}
+
+// UploadBulkChunk records a chunk of a participant file for BulkParticipate, only by the creator of the event.
+func (c *LotteryContract) UploadBulkChunk(ctx contractapi.TransactionContextInterface, request UploadBulkChunkRequest) (*BulkChunkReceipt, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	config, err := LoadLotteryConfig(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if txInfo.ClientID == "" || txInfo.ClientID != event.EventCreateTx.ClientID {
+		return nil, ErrUnauthorized.WithDetail("only the event creator can upload participant files")
+	}
+
+	return SaveBulkChunk(stubInterface, event, config, request)
+}
+
+// SetLotteryConfig records the configuration of the chaincode, only by administrators.
+func (c *LotteryContract) SetLotteryConfig(ctx contractapi.TransactionContextInterface, request SetLotteryConfigRequest) (*LotteryConfig, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+	if err := CheckAdmin(stubInterface); err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &LotteryConfig{
+		MaxBulkRows:      request.MaxBulkRows,
+		MaxBulkChunkSize: request.MaxBulkChunkSize,
+		MaxBulkChunks:    request.MaxBulkChunks,
+		MaxParticipant:   request.MaxParticipant,
+		UpdateTx:         txInfo,
+	}
+	if config.MaxParticipant == 0 {
+		config.MaxParticipant = DEFAULT_MAX_PARTICIPANT
+	}
+	err = config.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// WithdrawParticipation takes the participant out of the event before the deadline.
+// the participant record is kept and marked withdrawn, and the participant is not drawn.
+// participants are fixed once a round of the event is drawn, so the round can be verified.
+func (c *LotteryContract) WithdrawParticipation(ctx contractapi.TransactionContextInterface, request WithdrawParticipationRequest) (*Participant, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	rounds, err := LoadRounds(stubInterface, event.UUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, round := range rounds {
+		if round.Status == STATUS_DRAWN {
+			return nil, ErrInvalidStatus.WithDetail("a round of the event is drawn")
+		}
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	moved, err := event.Withdraw(request.ParticipantUUID, txInfo)
+	if err != nil {
+		return nil, err
+	}
+	err = event.RefundEntryFee(stubInterface, event.findParticipant(request.ParticipantUUID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWithdrawal(stubInterface, event.UUID, moved, event.ParticipantNum)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.findParticipant(request.ParticipantUUID), nil
+}
+
+// UpdateAllowlist rotates the allowlist root of a registered event, only by the creator of the event.
+// each rotation is a version of the event, listed by queryLotteryHistory.
+func (c *LotteryContract) UpdateAllowlist(ctx contractapi.TransactionContextInterface, request UpdateAllowlistRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+	if err := checkAllowlistArgs(request.AllowlistRoot, request.AllowlistLeaf); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventHeaderByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.RotateAllowlist(request.AllowlistRoot, request.AllowlistLeaf, txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// RemoveLotteryEvent closes the event before it is drawn, only by the creator of the event.
+// the entry fees of paid events are refunded to the participants and the prize pool to the creator.
+func (c *LotteryContract) RemoveLotteryEvent(ctx contractapi.TransactionContextInterface, request RemoveLotteryRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.Remove(txInfo)
+	if err != nil {
+		return nil, err
+	}
+	err = event.RefundEscrow(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (c *LotteryContract) EraseParticipantData(ctx contractapi.TransactionContextInterface, request EraseParticipantDataRequest) (*ErasureReceipt, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	participant := event.findParticipant(request.ParticipantUUID)
+	if participant == nil {
+		return nil, ErrParticipantNotFound.WithDetail(request.ParticipantUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !event.CanErase(participant, txInfo.ClientID) {
+		return nil, ErrUnauthorized.WithDetail("only the participant or the event creator can erase participant data")
+	}
+	commitment := participant.Commitment
+
+	err = event.EraseParticipant(request.ParticipantUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	rounds, err := LoadRounds(stubInterface, event.UUID)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range rounds {
+		rounds[idx].EraseParticipant(request.ParticipantUUID)
+		err = rounds[idx].SaveToLedger(stubInterface)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := MakeParticipantKey(stubInterface, event.UUID, request.ParticipantUUID)
+	if err != nil {
+		return nil, err
+	}
+	err = stubInterface.DelPrivateData(event.GetPrivateCollection(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &ErasureReceipt{
+		EventUUID:       event.UUID,
+		ParticipantUUID: request.ParticipantUUID,
+		Commitment:      commitment,
+		Reason:          request.Reason,
+		EraseTx:         txInfo,
+	}
+	err = receipt.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+func (c *LotteryContract) DisqualifyWinner(ctx contractapi.TransactionContextInterface, request DisqualifyWinnerRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	if request.Reason == "" {
+		return nil, ErrRequired("reason")
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	if prize := event.findPrize(request.PrizeUUID); prize != nil && prize.Payout > 0 {
+		return nil, ErrInvalidStatus.WithDetail("payouts of the prize are released by the draw")
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "disqualify winners"); err != nil {
+		return nil, err
+	}
+
+	err = event.DisqualifyWinner(
+		request.PrizeUUID,
+		request.ParticipantUUID,
+		request.Reason,
+		txInfo,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (c *LotteryContract) AddLotteryRound(ctx contractapi.TransactionContextInterface, request AddLotteryRoundRequest) (*Round, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+	if event.Status == STATUS_REMOVED {
+		return nil, ErrInvalidStatus.WithDetail("event is removed")
+	}
+
+	// check args is valid
+	if err := checkSeedArgs(event.DrawTypes, request.TargetBlock, request.ServiceProviderHash); err != nil {
+		return nil, err
+	}
+	if err := checkPrizeArgs(request.Prizes, request.DrawTime); err != nil {
+		return nil, err
+	}
+	pool, err := prizePool(request.Prizes)
+	if err != nil {
+		return nil, err
+	}
+	if pool > 0 {
+		return nil, ErrInvalidArg.WithField("payout").WithDetail("payouts are funded when the event is created")
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "add rounds"); err != nil {
+		return nil, err
+	}
+
+	round := NewRound(event, &request, txInfo)
+	err = round.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	event.RoundNum++
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return &round, nil
+}
+
+func (c *LotteryContract) DrawLotteryRound(ctx contractapi.TransactionContextInterface, request DrawLotteryRoundRequest) (*Round, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	rounds, err := LoadRounds(stubInterface, event.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if request.RoundIndex < 0 || request.RoundIndex >= int64(len(rounds)) {
+		return nil, ErrRoundNotFound.WithDetail(strconv.FormatInt(request.RoundIndex, 10))
+	}
+	round := &rounds[request.RoundIndex]
+
+	// check required input seed
+	for _, drawType := range event.DrawTypes {
+		switch drawType {
+		case DRAW_BLOCK_HASH:
+			if request.TargetBlock.Hash == "" {
+				return nil, ErrRequired("blockHash")
+			}
+			round.TargetBlock.Hash = request.TargetBlock.Hash
+			round.TargetBlock.Timestamp = request.TargetBlock.Timestamp
+			round.TargetBlock.Header = request.TargetBlock.Header
+			if err := round.TargetBlock.CheckHeader(); err != nil {
+				return nil, err
+			}
+		case DRAW_SERVICE_PROVIDER_HASH:
+			if request.ServiceProviderHash == "" {
+				return nil, ErrRequired("serviceProviderHash")
+			}
+			round.ServiceProviderHash = request.ServiceProviderHash
+		}
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "draw rounds"); err != nil {
+		return nil, err
+	}
+
+	err = round.Draw(event, rounds, txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = round.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return round, nil
+}
+
+func (c *LotteryContract) VerifyLotteryEvent(ctx contractapi.TransactionContextInterface, request VerifyLotteryRequest) (*VerifyResult, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(ctx.GetStub(), request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	result := event.Verify(request.InputHash)
+	return &result, nil
+}
+
+func (c *LotteryContract) VerifyLotteryRound(ctx contractapi.TransactionContextInterface, request VerifyLotteryRoundRequest) (*VerifyResult, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	round, err := LoadRound(stubInterface, event.UUID, request.RoundIndex)
+	if err != nil {
+		return nil, err
+	}
+	if round.EventUUID == "" {
+		return nil, ErrRoundNotFound.WithDetail(strconv.FormatInt(request.RoundIndex, 10))
+	}
+
+	result := round.Verify(event, request.InputHash)
+	return &result, nil
+}
+
+// GetDrawCertificate returns the draw certificate of the event, signed by the issuer key if the peer has one.
+func (c *LotteryContract) GetDrawCertificate(ctx contractapi.TransactionContextInterface, request GetDrawCertificateRequest) (*draw.SignedCertificate, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	certificate, err := event.MakeDrawCertificate()
+	if err != nil {
+		return nil, err
+	}
+	return SignDrawCertificate(certificate)
+}
+
+func (c *LotteryContract) ClaimPrize(ctx contractapi.TransactionContextInterface, request ClaimPrizeRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	authInformation := ""
+	privateData, err := LoadParticipantPrivateData(stubInterface, event, request.ParticipantUUID)
+	if err != nil {
+		return nil, err
+	}
+	if privateData != nil {
+		authInformation = privateData.AuthInformation
+	}
+
+	err = event.ClaimPrize(request.PrizeUUID, request.ParticipantUUID, request.Signature, authInformation, txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (c *LotteryContract) CloseClaims(ctx contractapi.TransactionContextInterface, request CloseClaimsRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	event, err := LoadEventByUUID(stubInterface, request.EventUUID)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" {
+		return nil, ErrEventNotFound.WithDetail(request.EventUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEventManager(stubInterface, event, txInfo, "close claims"); err != nil {
+		return nil, err
+	}
+
+	err = event.CloseClaims(txInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// checkTemplateArgs checks a template can spawn events.
+func checkTemplateArgs(request *CreateLotteryTemplateRequest) error {
+	if request.Recurrence.IntervalSeconds <= 0 {
+		return ErrRequired("recurrence.intervalSeconds")
+	}
+	for _, drawType := range request.DrawTypes {
+		switch drawType {
+		case DRAW_BLOCK_HASH:
+			if request.Recurrence.StartBlockHeight == 0 {
+				return ErrRequired("recurrence.startBlockHeight")
+			}
+		case DRAW_SERVICE_PROVIDER_HASH:
+			// service provider hash is given when the event is spawned
+		default:
+			return ErrUndefinedArg
+		}
+	}
+	if err := checkExclusionArgs(request.ExclusionRules); err != nil {
+		return err
+	}
+	// spawned events have no escrow, paid events are created with createLotteryEvent
+	pool, err := prizePool(request.Prizes)
+	if err != nil {
+		return err
+	}
+	if pool > 0 {
+		return ErrInvalidArg.WithField("payout").WithDetail("templates spawn free events")
+	}
+	return checkPrizeArgs(request.Prizes, 0)
+}
+
+func (c *LotteryContract) CreateLotteryTemplate(ctx contractapi.TransactionContextInterface, request CreateLotteryTemplateRequest) (*LotteryTemplate, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	if err := checkTemplateArgs(&request); err != nil {
+		return nil, err
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	template := NewLotteryTemplate(&request, txInfo)
+	err = template.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (c *LotteryContract) UpdateLotteryTemplate(ctx contractapi.TransactionContextInterface, request UpdateLotteryTemplateRequest) (*LotteryTemplate, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	if err := checkTemplateArgs(&request.CreateLotteryTemplateRequest); err != nil {
+		return nil, err
+	}
+
+	template, err := LoadTemplateByUUID(stubInterface, request.TemplateUUID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UUID == "" {
+		return nil, ErrTemplateNotFound.WithDetail(request.TemplateUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if txInfo.ClientID == "" || txInfo.ClientID != template.TemplateCreateTx.ClientID {
+		return nil, ErrUnauthorized.WithDetail("only the template creator can update the template")
+	}
+
+	template.Update(&request.CreateLotteryTemplateRequest, txInfo)
+	err = template.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (c *LotteryContract) QueryLotteryTemplate(ctx contractapi.TransactionContextInterface, request QueryLotteryTemplateRequest) (*LotteryTemplate, error) {
+	if err := ValidateRequest(ctx.GetStub(), request); err != nil {
+		return nil, err
+	}
+
+	if request.Version == 0 {
+		return LoadTemplateByUUID(ctx.GetStub(), request.TemplateUUID)
+	}
+	return LoadTemplateVersion(ctx.GetStub(), request.TemplateUUID, request.Version)
+}
+
+func (c *LotteryContract) SpawnFromTemplate(ctx contractapi.TransactionContextInterface, request SpawnFromTemplateRequest) (*Event, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+
+	template, err := LoadTemplateByUUID(stubInterface, request.TemplateUUID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UUID == "" {
+		return nil, ErrTemplateNotFound.WithDetail(request.TemplateUUID)
+	}
+
+	txInfo, err := NewTransaction(stubInterface, request.SubmitterID, request.SubmitterAddress)
+	if err != nil {
+		return nil, err
+	}
+	if txInfo.ClientID == "" || txInfo.ClientID != template.TemplateCreateTx.ClientID {
+		return nil, ErrUnauthorized.WithDetail("only the template creator can spawn events")
+	}
+
+	occurrence, err := template.NextOccurrence(txInfo.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	// spawned events are checked as createLotteryEvent checks them
+	createLotteryRequest := template.MakeCreateLotteryRequest(occurrence, request.ServiceProviderHash, txInfo)
+	if err := ValidateRequest(stubInterface, *createLotteryRequest); err != nil {
+		return nil, err
+	}
+	if err := checkCreateLotteryArgs(stubInterface, createLotteryRequest); err != nil {
+		return nil, err
+	}
+
+	event := NewEvent(createLotteryRequest, txInfo)
+	event.TemplateUUID = template.UUID
+	event.TemplateVersion = template.Version
+	event.TemplateOccurrence = occurrence
+
+	err = event.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	template.SpawnCount = occurrence + 1
+	err = template.SaveToLedger(stubInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// MigrateEvents rewrites a batch of events in the current schema versions. only lottery administrators can migrate.
+// call it with the returned bookmark until the bookmark is empty.
+func (c *LotteryContract) MigrateEvents(ctx contractapi.TransactionContextInterface, request MigrateEventsRequest) (*MigrationResult, error) {
+	stubInterface := ctx.GetStub()
+
+	if err := ValidateRequest(stubInterface, request); err != nil {
+		return nil, err
+	}
+	if err := CheckAdmin(stubInterface); err != nil {
+		return nil, err
+	}
+
+	return MigrateEvents(stubInterface, request.PageSize, request.Bookmark)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+)
+
+func TestLotteryContractFunctions(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+
+	createRequest := []byte(`{"eventName":"event","deadlineTime":` + strconv.FormatInt(time.Now().Unix()+1, 10) +
+		`,"maxParticipant":10,"drawTypes":["DRAW_SERVICE_PROVIDER"],"prizes":[{"title":"prize","winnerNum":1}],"serviceProviderHash":"providerHash"}`)
+	res := m.MockInvoke("createTx", [][]byte{[]byte("CreateLotteryEvent"), createRequest})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := &Event{}
+	if err := json.Unmarshal(res.Payload, event); err != nil || event.UUID == "" {
+		t.Fatalf("unexpected payload : %s", res.Payload)
+	}
+
+	queryRequest := `{"queryType":"QUERY_BY_EVENT_ID","eventUUID":"` + event.UUID + `"}`
+	res = m.MockInvoke("queryTx", [][]byte{[]byte(LOTTERY_CONTRACT_NAME + ":QueryLotteryEventByID"), []byte(queryRequest)})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	// the same request body is accepted by the legacy form
+	res = m.MockInvoke("queryTx", [][]byte{[]byte("invoke"), []byte("queryLotteryEvent"), []byte(queryRequest)})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	res = m.MockInvoke("queryTx", [][]byte{[]byte("QueryLotteryEventByID"), []byte(`{"eventUUID":"` + event.UUID + `","unknown":1}`)})
+	if res.Status == shim.OK {
+		t.Error("request out of the metadata schema must fail")
+	}
+
+	time.Sleep(2 * time.Second)
+	res = m.MockInvoke("participateTx", [][]byte{[]byte("ParticipateLotteryEvent"), []byte(`{"eventUUID":"` + event.UUID + `","participant":{"UUID":"participant1"}}`)})
+	if res.Status == shim.OK || !strings.HasPrefix(res.Message, string(ErrDeadlinePassed.Code)) {
+		t.Errorf("expected %s, got %s", ErrDeadlinePassed, res.Message)
+	}
+}
+
+func TestLotteryContractMetadata(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+
+	res := m.MockInvoke("metadataTx", [][]byte{[]byte("org.hyperledger.fabric:GetMetadata")})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	contractMetadata := &metadata.ContractChaincodeMetadata{}
+	if err := json.Unmarshal(res.Payload, contractMetadata); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := make(map[string]string)
+	for _, transaction := range contractMetadata.Contracts[LOTTERY_CONTRACT_NAME].Transactions {
+		tags[transaction.Name] = strings.Join(transaction.Tag, ",")
+	}
+	if tags["CreateLotteryEvent"] != "submit" || tags["QueryLotteryEventByID"] != "evaluate" {
+		t.Errorf("unexpected transaction tags : %v", tags)
+	}
+	for _, name := range []string{"CreateLotteryRequest", "Event", "Prize", "VerifyResult"} {
+		if _, exist := contractMetadata.Components.Schemas[name]; !exist {
+			t.Errorf("schema of %s is not in the metadata", name)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+// DocType discriminates stored objects in rich queries.
+type DocType string
+
+const (
+	DOC_TYPE_CONFIG              DocType = "lotteryConfig"
+	DOC_TYPE_DRAW_STATE          DocType = "drawState"
+	DOC_TYPE_EVENT               DocType = "event"
+	DOC_TYPE_ERASURE_RECEIPT     DocType = "erasureReceipt"
+	DOC_TYPE_PARTICIPANT         DocType = "participant"
+	DOC_TYPE_PARTICIPANT_PRIVATE DocType = "participantPrivate"
+	DOC_TYPE_ROUND               DocType = "round"
+	DOC_TYPE_TEMPLATE            DocType = "template"
+	DOC_TYPE_TEMPLATE_VERSION    DocType = "templateVersion"
+	DOC_TYPE_WIN_RECORD          DocType = "winRecord"
+)
@@ -0,0 +1,80 @@
+package draw
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+// BITCOIN_HEADER_SIZE is the size of a serialized bitcoin block header in bytes.
+const BITCOIN_HEADER_SIZE = 80
+
+// BitcoinHeader is a parsed bitcoin block header. hashes are hex in the usual reversed byte order.
+type BitcoinHeader struct {
+	Version    int32  `json:"version"`
+	PrevBlock  string `json:"prevBlock"`
+	MerkleRoot string `json:"merkleRoot"`
+	Timestamp  int64  `json:"time"`
+	Bits       uint32 `json:"bits"`
+	Nonce      uint32 `json:"nonce"`
+	Hash       string `json:"hash"`
+}
+
+// ParseBitcoinHeader parses the hex encoded 80 bytes header, such as `bitcoin-cli getblockheader {hash} false` returns.
+func ParseBitcoinHeader(hexHeader string) (*BitcoinHeader, error) {
+	raw, err := hex.DecodeString(hexHeader)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != BITCOIN_HEADER_SIZE {
+		return nil, errors.New("bitcoin header must be 80 bytes")
+	}
+
+	first := sha256.Sum256(raw)
+	hash := sha256.Sum256(first[:])
+	return &BitcoinHeader{
+		Version:    int32(binary.LittleEndian.Uint32(raw[0:4])),
+		PrevBlock:  reversedHex(raw[4:36]),
+		MerkleRoot: reversedHex(raw[36:68]),
+		Timestamp:  int64(binary.LittleEndian.Uint32(raw[68:72])),
+		Bits:       binary.LittleEndian.Uint32(raw[72:76]),
+		Nonce:      binary.LittleEndian.Uint32(raw[76:80]),
+		Hash:       reversedHex(hash[:]),
+	}, nil
+}
+
+// CheckProofOfWork checks the header hash is not above the target of the header bits.
+// the difficulty of the bits itself is not checked against the chain.
+func (h *BitcoinHeader) CheckProofOfWork() error {
+	exponent := uint(h.Bits >> 24)
+	mantissa := big.NewInt(int64(h.Bits & 0x007fffff))
+	if h.Bits&0x00800000 != 0 || mantissa.Sign() == 0 {
+		return errors.New("bitcoin header bits is not a valid target")
+	}
+
+	target := new(big.Int)
+	if exponent <= 3 {
+		target.Rsh(mantissa, 8*(3-exponent))
+	} else {
+		target.Lsh(mantissa, 8*(exponent-3))
+	}
+
+	hash, ok := new(big.Int).SetString(h.Hash, 16)
+	if !ok {
+		return errors.New("bitcoin header hash is not hex")
+	}
+	if hash.Cmp(target) > 0 {
+		return errors.New("bitcoin header hash is above the target")
+	}
+	return nil
+}
+
+func reversedHex(b []byte) string {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return hex.EncodeToString(reversed)
+}
@@ -0,0 +1,28 @@
+package draw
+
+import "testing"
+
+const genesisHeader = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+
+func TestParseBitcoinHeader(t *testing.T) {
+	header, err := ParseBitcoinHeader(genesisHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash != "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f" || header.Timestamp != 1231006505 || header.Nonce != 2083236893 {
+		t.Errorf("unexpected header : %+v", header)
+	}
+	if err = header.CheckProofOfWork(); err != nil {
+		t.Error(err)
+	}
+
+	// another nonce does not meet the target
+	tampered, _ := ParseBitcoinHeader(genesisHeader[:152] + "00000000")
+	if tampered.CheckProofOfWork() == nil {
+		t.Error("tampered header must not meet the target")
+	}
+
+	if _, err = ParseBitcoinHeader(genesisHeader[:158]); err == nil {
+		t.Error("short header must be rejected")
+	}
+}
@@ -0,0 +1,216 @@
+package draw
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/sslab-archive/block_lottery_cc/merkle"
+)
+
+// CERTIFICATE_VERSION is the version of the certificate format.
+const CERTIFICATE_VERSION = "1"
+
+// Certificate is the self-contained proof of a draw. it holds every input of the draw,
+// so the winners are re-derived without ledger access.
+// the canonical form is the compact JSON of the struct, fields in the declared order.
+type Certificate struct {
+	Version        string   `json:"version"`
+	EventUUID      string   `json:"eventUUID"`
+	EventName      string   `json:"eventName"`
+	DeadlineTime   int64    `json:"deadlineTime"`
+	MaxParticipant int64    `json:"maxParticipant"`
+	DrawTypes      []string `json:"drawTypes"`
+
+	Participants    []CertificateParticipant `json:"participants"`    // drawing participants in the participation order
+	ParticipantRoot string                   `json:"participantRoot"` // merkle root of the participant leaves
+
+	Seed       CertificateSeed       `json:"seed"`
+	Algorithms CertificateAlgorithms `json:"algorithms"`
+	Prizes     []CertificatePrize    `json:"prizes"`
+
+	DrawTxID string `json:"drawTxID"`
+	DrawTime int64  `json:"drawTime"` // timestamp of the draw transaction
+}
+
+type CertificateParticipant struct {
+	UUID       string `json:"UUID"`
+	Commitment string `json:"commitment"`
+}
+
+// Leaf returns the merkle leaf of the participant ( {UUID}:{commitment} ).
+func (p CertificateParticipant) Leaf() []byte {
+	return []byte(p.UUID + ":" + p.Commitment)
+}
+
+type CertificateSeed struct {
+	BlockType           string `json:"blockType"`
+	BlockHeight         int64  `json:"blockHeight"`
+	BlockHash           string `json:"blockHash"`
+	BlockHeader         string `json:"blockHeader"` // hex encoded header, empty if not recorded
+	ServiceProviderHash string `json:"serviceProviderHash"`
+	SeedHash            string `json:"seedHash"`
+}
+
+type CertificateAlgorithms struct {
+	Seed    string `json:"seed"`
+	Shuffle string `json:"shuffle"`
+	Assign  string `json:"assign"`
+	Merkle  string `json:"merkle"`
+}
+
+// CurrentAlgorithms returns the algorithm versions of this package.
+func CurrentAlgorithms() CertificateAlgorithms {
+	return CertificateAlgorithms{
+		Seed:    SEED_ALGORITHM,
+		Shuffle: SHUFFLE_ALGORITHM,
+		Assign:  ASSIGN_ALGORITHM,
+		Merkle:  merkle.ALGORITHM,
+	}
+}
+
+type CertificatePrize struct {
+	UUID         string   `json:"UUID"`
+	Title        string   `json:"title"`
+	WinnerNum    int64    `json:"winnerNum"`
+	AlternateNum int64    `json:"alternateNum"`
+	Winners      []string `json:"winners"`
+	Alternates   []string `json:"alternates"`
+	Disqualified []string `json:"disqualified"` // disqualified winners in the disqualification order
+}
+
+// ParticipantLeaves returns the merkle leaves of the participants.
+func (c *Certificate) ParticipantLeaves() [][]byte {
+	leaves := make([][]byte, len(c.Participants))
+	for idx, participant := range c.Participants {
+		leaves[idx] = participant.Leaf()
+	}
+	return leaves
+}
+
+// CanonicalJSON returns the signed bytes of the certificate.
+func (c *Certificate) CanonicalJSON() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// SignedCertificate is the certificate with the issuer signature.
+// Signer and Signature are empty when the certificate is not signed.
+type SignedCertificate struct {
+	Certificate Certificate `json:"certificate"`
+	Signer      string      `json:"signer"`    // PEM encoded X.509 certificate or public key of the issuer
+	Signature   string      `json:"signature"` // base64 ASN.1 ECDSA signature over sha256 of the canonical JSON
+}
+
+// Sign signs the canonical JSON of the certificate with the issuer key.
+// signerPEM is the PEM encoded certificate of the key, the public key is embedded if it is empty.
+func (s *SignedCertificate) Sign(key *ecdsa.PrivateKey, signerPEM string) error {
+	b, err := s.Certificate.CanonicalJSON()
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(b)
+	signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+
+	if signerPEM == "" {
+		publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return err
+		}
+		signerPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	}
+	s.Signer = signerPEM
+	s.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// VerifySignature checks the signature with the embedded signer.
+func (s *SignedCertificate) VerifySignature() error {
+	if s.Signature == "" {
+		return errors.New("certificate is not signed")
+	}
+	publicKey, err := ParseSignerPublicKey(s.Signer)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.Certificate.CanonicalJSON()
+	if err != nil {
+		return err
+	}
+	parsed := struct{ R, S *big.Int }{}
+	if _, err = asn1.Unmarshal(signature, &parsed); err != nil {
+		return err
+	}
+	digest := sha256.Sum256(b)
+	if !ecdsa.Verify(publicKey, digest[:], parsed.R, parsed.S) {
+		return errors.New("certificate signature is not valid")
+	}
+	return nil
+}
+
+// ParseSignerPublicKey reads the ECDSA public key of a PEM encoded X.509 certificate or public key.
+func ParseSignerPublicKey(signerPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(signerPEM))
+	if block == nil {
+		return nil, errors.New("signer is not PEM encoded")
+	}
+
+	var publicKey interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = cert.PublicKey
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	default:
+		return nil, errors.New("signer must be a certificate or a public key")
+	}
+
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("signer key is not ECDSA")
+	}
+	return ecdsaKey, nil
+}
+
+// ParseIssuerKey reads a PEM encoded ECDSA private key in SEC 1 or PKCS #8 form.
+func ParseIssuerKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("issuer key is not PEM encoded")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("issuer key is not ECDSA")
+	}
+	return ecdsaKey, nil
+}
@@ -0,0 +1,167 @@
+// Package draw is the draw algorithm shared by the lottery chaincode and the offline verifier.
+// every function is deterministic, so a draw is replayed from the recorded seed inputs.
+package draw
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+)
+
+// versions of the algorithms, recorded with the draw results.
+const (
+	SEED_ALGORITHM    = "concat-plus/1"         // MakeSeed
+	SHUFFLE_ALGORITHM = "fisher-yates-sha256/1" // ShuffleIndices
+	ASSIGN_ALGORITHM  = "winners-then-alternates/1"
+)
+
+// MakeSeed concatenates the seed inputs of a draw.
+func MakeSeed(blockHash string, serviceProviderHash string) string {
+	return blockHash + "_PLUS_" + serviceProviderHash
+}
+
+// ShuffleIndices returns the shuffled order of n items.
+// position j is swapped with sha256(seed + j) mod n, from the last position down to 1.
+func ShuffleIndices(n int, seed string) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	hashInput := newHashInput(seed)
+	for j := n - 1; j > 0; j-- {
+		k := hashInput.swapPosition(n, j)
+		order[j], order[k] = order[k], order[j]
+	}
+	return order
+}
+
+// ShufflePrefix returns the first m positions of ShuffleIndices(n, seed), holding only m positions in memory.
+// the swaps are undone from position 1 up for the traced positions, so it makes the same n hashes.
+func ShufflePrefix(n int, m int, seed string) []int {
+	trace := NewPrefixTrace(n, m)
+	trace.Step(seed, n)
+	return trace.Positions
+}
+
+// PrefixTrace is the state of ShufflePrefix between steps, so a trace can span several transactions.
+type PrefixTrace struct {
+	N         int   `json:"n"`         // items of the shuffle
+	NextSwap  int   `json:"nextSwap"`  // next position to undo the swap of
+	Positions []int `json:"positions"` // current position of each prefix item, the prefix when the trace is done
+}
+
+// NewPrefixTrace starts the trace of the first m positions of a shuffle of n items.
+func NewPrefixTrace(n int, m int) *PrefixTrace {
+	if m > n {
+		m = n
+	}
+	positions := make([]int, m)
+	for p := range positions {
+		positions[p] = p
+	}
+	return &PrefixTrace{N: n, NextSwap: 1, Positions: positions}
+}
+
+// Done reports whether every swap is undone.
+func (t *PrefixTrace) Done() bool {
+	return t.NextSwap >= t.N
+}
+
+// Step undoes up to maxSwaps swaps of the trace.
+func (t *PrefixTrace) Step(seed string, maxSwaps int) {
+	// traced maps the current position of a traced item to its position in the prefix
+	traced := make(map[int]int, len(t.Positions))
+	for p, position := range t.Positions {
+		traced[position] = p
+	}
+
+	hashInput := newHashInput(seed)
+	for swaps := 0; t.NextSwap < t.N && swaps < maxSwaps; swaps++ {
+		j := t.NextSwap
+		t.NextSwap++
+		k := hashInput.swapPosition(t.N, j)
+		pj, tracedJ := traced[j]
+		pk, tracedK := traced[k]
+		if !tracedJ && !tracedK {
+			continue
+		}
+		delete(traced, j)
+		delete(traced, k)
+		if tracedJ {
+			traced[k] = pj
+		}
+		if tracedK {
+			traced[j] = pk
+		}
+	}
+
+	for position, p := range traced {
+		t.Positions[p] = position
+	}
+}
+
+// hashInput is the seed followed by room for a position, reused for every swap.
+type hashInput []byte
+
+func newHashInput(seed string) hashInput {
+	input := make([]byte, len(seed), len(seed)+20)
+	copy(input, seed)
+	return input
+}
+
+// swapPosition returns the position swapped with position j, sha256(seed + j) mod n.
+func (h hashInput) swapPosition(n int, j int) int {
+	hash := sha256.Sum256(strconv.AppendInt(h, int64(j), 10))
+	return int(binary.BigEndian.Uint64(hash[:]) % uint64(n))
+}
+
+// PrizeSlots is the number of winners and alternates of a prize.
+type PrizeSlots struct {
+	WinnerNum    int64
+	AlternateNum int64
+}
+
+// Assignment is the shuffled positions taken by a prize.
+type Assignment struct {
+	Winners    []int
+	Alternates []int
+}
+
+// Assign hands out the shuffled positions in prize order.
+// winners of every prize are taken first, and the rest of the shuffle fills the alternates.
+// later prizes get fewer or no winners when there are not enough positions.
+func Assign(n int, prizes []PrizeSlots) []Assignment {
+	assignments := make([]Assignment, len(prizes))
+	passed := 0
+	for idx, prize := range prizes {
+		assignments[idx].Winners = take(n, &passed, prize.WinnerNum)
+	}
+	for idx, prize := range prizes {
+		assignments[idx].Alternates = take(n, &passed, prize.AlternateNum)
+	}
+	return assignments
+}
+
+func take(n int, passed *int, num int64) []int {
+	taken := make([]int, 0)
+	for i := int64(0); i < num && *passed < n; i++ {
+		taken = append(taken, *passed)
+		*passed++
+	}
+	return taken
+}
+
+// Disqualify removes the winner at the position and promotes the first alternate into the same position.
+// it returns the promoted alternate, or -1 if no alternate was left.
+func (a *Assignment) Disqualify(position int) int {
+	if len(a.Alternates) == 0 {
+		a.Winners = append(a.Winners[:position], a.Winners[position+1:]...)
+		return -1
+	}
+
+	promoted := a.Alternates[0]
+	a.Winners[position] = promoted
+	a.Alternates = a.Alternates[1:]
+	return promoted
+}
@@ -0,0 +1,107 @@
+package draw
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+	"testing"
+)
+
+func TestShuffleIndices(t *testing.T) {
+	// the in-place shuffle of the first chaincode release
+	arr := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	for j := len(arr) - 1; j > 0; j-- {
+		hash := sha256.Sum256([]byte("testSource" + strconv.Itoa(j)))
+		k := binary.BigEndian.Uint64(hash[:]) % uint64(len(arr))
+		arr[j], arr[k] = arr[k], arr[j]
+	}
+
+	order := ShuffleIndices(len(arr), "testSource")
+	for i := range arr {
+		if order[i] != arr[i] {
+			t.Fatalf("expected %v, got %v", arr, order)
+		}
+	}
+
+	if len(ShuffleIndices(0, "testSource")) != 0 || ShuffleIndices(1, "testSource")[0] != 0 {
+		t.Error("unexpected shuffle of empty or single item")
+	}
+}
+
+func TestShufflePrefix(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 100, 1000} {
+		order := ShuffleIndices(n, "testSource")
+		for _, m := range []int{0, 1, 3, n / 2, n, n + 5} {
+			prefix := ShufflePrefix(n, m, "testSource")
+			if m > n {
+				m = n
+			}
+			if len(prefix) != m {
+				t.Fatalf("n %d m %d : prefix length is %d", n, m, len(prefix))
+			}
+			for i := range prefix {
+				if prefix[i] != order[i] {
+					t.Fatalf("n %d m %d : expected %v, got %v", n, m, order[:m], prefix)
+				}
+			}
+		}
+	}
+}
+
+// a trace stepped in chunks ends with the same prefix, so a draw can be split over transactions.
+func TestPrefixTraceSteps(t *testing.T) {
+	for _, chunk := range []int{1, 7, 64} {
+		trace := NewPrefixTrace(1000, 30)
+		for steps := 0; !trace.Done(); steps++ {
+			if steps > 1000 {
+				t.Fatal("trace is not done")
+			}
+			trace.Step("testSource", chunk)
+		}
+
+		prefix := ShufflePrefix(1000, 30, "testSource")
+		for i := range prefix {
+			if trace.Positions[i] != prefix[i] {
+				t.Fatalf("chunk %d : expected %v, got %v", chunk, prefix, trace.Positions)
+			}
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	assignments := Assign(6, []PrizeSlots{{WinnerNum: 1, AlternateNum: 2}, {WinnerNum: 2, AlternateNum: 2}})
+
+	expected := [][]int{{0}, {1, 2}, {3, 4}, {5}}
+	actual := [][]int{assignments[0].Winners, assignments[1].Winners, assignments[0].Alternates, assignments[1].Alternates}
+	for i := range expected {
+		if len(expected[i]) != len(actual[i]) {
+			t.Fatalf("group %d : expected %v, got %v", i, expected[i], actual[i])
+		}
+		for j := range expected[i] {
+			if expected[i][j] != actual[i][j] {
+				t.Errorf("group %d : expected %v, got %v", i, expected[i], actual[i])
+			}
+		}
+	}
+
+	if promoted := assignments[1].Disqualify(0); promoted != 5 || assignments[1].Winners[0] != 5 || len(assignments[1].Alternates) != 0 {
+		t.Errorf("unexpected promotion : %d, %+v", promoted, assignments[1])
+	}
+	if promoted := assignments[1].Disqualify(1); promoted != -1 || len(assignments[1].Winners) != 1 {
+		t.Errorf("winner must be removed without alternates : %d, %+v", promoted, assignments[1])
+	}
+}
+
+const benchmarkParticipants = 1000000
+
+func BenchmarkShuffleIndices(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ShuffleIndices(benchmarkParticipants, "benchmarkSource")
+	}
+}
+
+func BenchmarkShufflePrefix(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ShufflePrefix(benchmarkParticipants, 100, "benchmarkSource")
+	}
+}
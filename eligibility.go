@@ -0,0 +1,176 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+type EligibilityType string
+
+const (
+	ELIGIBLE_MIN_ACCOUNT_AGE   EligibilityType = "ELIGIBLE_MIN_ACCOUNT_AGE"   // Attribute holds the UNIX time the account was opened, at least MinAgeSeconds ago
+	ELIGIBLE_MSP               EligibilityType = "ELIGIBLE_MSP"               // client MSP ID is one of Values
+	ELIGIBLE_ATTRIBUTE         EligibilityType = "ELIGIBLE_ATTRIBUTE"         // certificate attribute Attribute is one of Values
+	ELIGIBLE_PARTICIPANT_FIELD EligibilityType = "ELIGIBLE_PARTICIPANT_FIELD" // participant Field matches Pattern
+)
+
+// participant fields eligibility rules can check. the PII is read from the private data,
+// since it is separated from the participant before the participant is recorded.
+var eligibilityFields = map[string]func(p *Participant, d *ParticipantPrivateData) string{
+	"UUID":            func(p *Participant, d *ParticipantPrivateData) string { return p.UUID },
+	"information":     func(p *Participant, d *ParticipantPrivateData) string { return d.Information },
+	"authInformation": func(p *Participant, d *ParticipantPrivateData) string { return d.AuthInformation },
+}
+
+// EligibilityRule admits participants by their client identity and participant fields, checked at participation time.
+// attributes are the ones attested by the fabric CA in the client certificate, so every peer evaluates a rule the same way.
+type EligibilityRule struct {
+	Type          EligibilityType `json:"type"`
+	Name          string          `json:"name" metadata:",optional"`          // reported on rejection
+	Attribute     string          `json:"attribute" metadata:",optional"`     // certificate attribute of ELIGIBLE_MIN_ACCOUNT_AGE and ELIGIBLE_ATTRIBUTE
+	Values        []string        `json:"values" metadata:",optional"`        // allowed MSP IDs or attribute values
+	MinAgeSeconds int64           `json:"minAgeSeconds" metadata:",optional"` // minimum account age of ELIGIBLE_MIN_ACCOUNT_AGE
+	Field         string          `json:"field" metadata:",optional"`         // participant field of ELIGIBLE_PARTICIPANT_FIELD
+	Pattern       string          `json:"pattern" metadata:",optional"`       // RE2 expression the whole field must match
+}
+
+// CheckEligibility checks the participant and the invoking client against the eligibility rules of the event.
+// it returns ErrNotEligible with the failed rule as the field.
+func (e *Event) CheckEligibility(stubInterface shim.ChaincodeStubInterface, participant *Participant, privateData *ParticipantPrivateData, txTimestamp int64) error {
+	if len(e.EligibilityRules) == 0 {
+		return nil
+	}
+
+	// rules on the client identity reject clients without a readable X.509 identity
+	clientIdentity, err := cid.New(stubInterface)
+	if err != nil {
+		clientIdentity = nil
+	}
+	return e.checkEligibilityRules(clientIdentity, participant, privateData, txTimestamp)
+}
+
+// CheckFieldEligibility checks the participant fields against the eligibility rules, without a client identity.
+// rows of bulkParticipate are checked with it, events with rules on the client identity do not take rows.
+func (e *Event) CheckFieldEligibility(participant *Participant, privateData *ParticipantPrivateData) error {
+	return e.checkEligibilityRules(nil, participant, privateData, 0)
+}
+
+// hasIdentityRules reports whether a rule of the event checks the client identity.
+func (e *Event) hasIdentityRules() bool {
+	for _, rule := range e.EligibilityRules {
+		if rule.Type != ELIGIBLE_PARTICIPANT_FIELD {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Event) checkEligibilityRules(clientIdentity cid.ClientIdentity, participant *Participant, privateData *ParticipantPrivateData, txTimestamp int64) error {
+	for idx, rule := range e.EligibilityRules {
+		reason := rule.check(clientIdentity, participant, privateData, txTimestamp)
+		if reason == "" {
+			continue
+		}
+
+		name := rule.Name
+		if name == "" {
+			name = string(rule.Type)
+		}
+		return ErrNotEligible.WithField("eligibilityRules[" + strconv.Itoa(idx) + "]").WithDetail(name + " : " + reason)
+	}
+	return nil
+}
+
+// check returns the reason the rule rejects the participant, or empty string if the participant is eligible.
+func (r *EligibilityRule) check(clientIdentity cid.ClientIdentity, participant *Participant, privateData *ParticipantPrivateData, txTimestamp int64) string {
+	if r.Type == ELIGIBLE_PARTICIPANT_FIELD {
+		field, exist := eligibilityFields[r.Field]
+		if !exist {
+			return "field " + r.Field + " is not defined"
+		}
+		matched, err := regexp.MatchString("^(?:"+r.Pattern+")$", field(participant, privateData))
+		if err != nil || !matched {
+			return r.Field + " does not match the pattern"
+		}
+		return ""
+	}
+
+	if clientIdentity == nil {
+		return "client identity is not readable"
+	}
+	switch r.Type {
+	case ELIGIBLE_MSP:
+		mspID, err := clientIdentity.GetMSPID()
+		if err != nil || !containsValue(r.Values, mspID) {
+			return "MSP " + mspID + " is not allowed"
+		}
+	case ELIGIBLE_ATTRIBUTE:
+		value, found, err := clientIdentity.GetAttributeValue(r.Attribute)
+		if err != nil || !found || !containsValue(r.Values, value) {
+			return "attribute " + r.Attribute + " is not allowed"
+		}
+	case ELIGIBLE_MIN_ACCOUNT_AGE:
+		value, found, err := clientIdentity.GetAttributeValue(r.Attribute)
+		if err != nil || !found {
+			return "attribute " + r.Attribute + " is not attested"
+		}
+		openTime, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "attribute " + r.Attribute + " is not a UNIX time"
+		}
+		if txTimestamp-openTime < r.MinAgeSeconds {
+			return "account is younger than " + strconv.FormatInt(r.MinAgeSeconds, 10) + " seconds"
+		}
+	default:
+		return "rule type is not defined"
+	}
+	return ""
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEligibilityArgs checks eligibility rules are defined and have the inputs of their type.
+func checkEligibilityArgs(rules []EligibilityRule) error {
+	for _, rule := range rules {
+		switch rule.Type {
+		case ELIGIBLE_MIN_ACCOUNT_AGE:
+			if rule.Attribute == "" {
+				return ErrRequired("attribute")
+			}
+			if rule.MinAgeSeconds <= 0 {
+				return ErrRequired("minAgeSeconds")
+			}
+		case ELIGIBLE_MSP:
+			if len(rule.Values) == 0 {
+				return ErrRequired("values")
+			}
+		case ELIGIBLE_ATTRIBUTE:
+			if rule.Attribute == "" {
+				return ErrRequired("attribute")
+			}
+			if len(rule.Values) == 0 {
+				return ErrRequired("values")
+			}
+		case ELIGIBLE_PARTICIPANT_FIELD:
+			if _, exist := eligibilityFields[rule.Field]; !exist {
+				return ErrInvalidArg.WithField("field").WithDetail("field " + rule.Field + " is not defined")
+			}
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return ErrInvalidArg.WithField("pattern").WithDetail(err.Error())
+			}
+		default:
+			return ErrUndefinedArg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/x509"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// clientIdentity is a client identity with fixed MSP ID and attributes.
+type clientIdentity struct {
+	mspID      string
+	attributes map[string]string
+}
+
+func (c *clientIdentity) GetID() (string, error)                         { return "x509::CN=client::CN=ca", nil }
+func (c *clientIdentity) GetMSPID() (string, error)                      { return c.mspID, nil }
+func (c *clientIdentity) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+func (c *clientIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := c.attributes[name]
+	return value, found, nil
+}
+
+func (c *clientIdentity) AssertAttributeValue(name string, value string) error {
+	if c.attributes[name] != value {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func TestCheckEligibilityRules(t *testing.T) {
+	event := &Event{
+		EligibilityRules: []EligibilityRule{
+			{Type: ELIGIBLE_MSP, Values: []string{"Org1MSP", "Org2MSP"}},
+			{Type: ELIGIBLE_ATTRIBUTE, Name: "members only", Attribute: "membership", Values: []string{"gold", "silver"}},
+			{Type: ELIGIBLE_MIN_ACCOUNT_AGE, Attribute: "account.openTime", MinAgeSeconds: 1000},
+			{Type: ELIGIBLE_PARTICIPANT_FIELD, Field: "information", Pattern: `[^@]+@example\.com`},
+		},
+	}
+	eligible := func() *clientIdentity {
+		return &clientIdentity{mspID: "Org1MSP", attributes: map[string]string{"membership": "gold", "account.openTime": "1000"}}
+	}
+	participant := &Participant{UUID: "participant"}
+	privateData := &ParticipantPrivateData{Information: "alice@example.com"}
+
+	if err := event.checkEligibilityRules(eligible(), participant, privateData, 2000); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		field     string
+		identity  *clientIdentity
+		tx        int64
+		reference string
+	}{
+		{"eligibilityRules[0]", &clientIdentity{mspID: "Org3MSP", attributes: eligible().attributes}, 2000, "foreign MSP"},
+		{"eligibilityRules[1]", &clientIdentity{mspID: "Org1MSP", attributes: map[string]string{"membership": "bronze"}}, 2000, "attribute value"},
+		{"eligibilityRules[2]", eligible(), 1999, "young account"},
+		{"eligibilityRules[2]", &clientIdentity{mspID: "Org1MSP", attributes: map[string]string{"membership": "gold"}}, 2000, "unattested account"},
+	}
+	for _, c := range cases {
+		err := event.checkEligibilityRules(c.identity, participant, privateData, c.tx)
+		if !ErrNotEligible.Is(err) || err.(*LotteryError).Field != c.field {
+			t.Errorf("%s : expected %s, got %v", c.reference, c.field, err)
+		}
+	}
+
+	err := event.checkEligibilityRules(eligible(), participant, &ParticipantPrivateData{Information: "alice@example.com.evil"}, 2000)
+	if !ErrNotEligible.Is(err) || err.(*LotteryError).Field != "eligibilityRules[3]" {
+		t.Errorf("field is matched partially : %v", err)
+	}
+
+	// the rule name is reported
+	err = event.checkEligibilityRules(&clientIdentity{mspID: "Org1MSP"}, participant, privateData, 2000)
+	if err == nil || err.(*LotteryError).Message != ErrNotEligible.Message+" : members only : attribute membership is not allowed" {
+		t.Errorf("unexpected rejection : %v", err)
+	}
+
+	// rules on the identity reject unreadable clients
+	if err = event.checkEligibilityRules(nil, participant, privateData, 2000); !ErrNotEligible.Is(err) {
+		t.Error("client without identity is eligible")
+	}
+}
+
+func TestParticipateEligibility(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	request := CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+		EligibilityRules: []EligibilityRule{
+			{Type: ELIGIBLE_MIN_ACCOUNT_AGE, Attribute: "account.openTime"},
+		},
+
+		ServiceProviderHash: "providerHash",
+	}
+	if _, ok := m.call(t, "createTx", "createLotteryEvent", request); ok {
+		t.Fatal("rule without minimum age is accepted")
+	}
+	request.EligibilityRules[0].MinAgeSeconds = 86400
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", request)
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	openTime := func(age int64) map[string]string {
+		return map[string]string{"account.openTime": strconv.FormatInt(time.Now().Unix()-age, 10)}
+	}
+	m.setClientWithAttributes(t, "newcomer", openTime(3600))
+	if _, ok = m.call(t, "participateTx1", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "newcomer"}}); ok {
+		t.Fatal("young account joined")
+	}
+	m.setClientWithAttributes(t, "member", openTime(2*86400))
+	if _, ok = m.call(t, "participateTx2", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "member"}}); !ok {
+		t.Fatal("old account is rejected")
+	}
+}
+
+func TestParticipateFieldEligibility(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+		EligibilityRules: []EligibilityRule{
+			{Type: ELIGIBLE_PARTICIPANT_FIELD, Field: "information", Pattern: `[^@]+@example\.com`},
+		},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	// the PII comes only in the transient map
+	m.setClient(t, "mallory")
+	m.setPrivateData("mallory@evil.com", "")
+	if _, ok = m.call(t, "participateTx1", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "mallory"}}); ok {
+		t.Fatal("participant out of the pattern joined")
+	}
+	m.setClient(t, "alice")
+	m.setPrivateData("alice@example.com", "")
+	if _, ok = m.call(t, "participateTx2", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "alice"}}); !ok {
+		t.Fatal("participant matching the pattern is rejected")
+	}
+}
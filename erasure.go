@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ErasureReceipt records the erasure of participant PII from the private data state.
+// the participant UUID and the commitment are kept, so draws are still replayed and verified.
+// the private data written by earlier blocks stays on the peers until the blockToLive of the collection purges it.
+type ErasureReceipt struct {
+	DocType         DocType     `json:"docType"`
+	SchemaVersion   int64       `json:"schemaVersion"`
+	EventUUID       string      `json:"eventUUID"`
+	ParticipantUUID string      `json:"participantUUID"`
+	Commitment      string      `json:"commitment"`
+	Reason          string      `json:"reason"`
+	EraseTx         Transaction `json:"eraseTx"`
+}
+
+// MakeErasureReceiptKey returns composite key of the erasure receipt ( erasure~{eventUUID}~{participantUUID} )
+func MakeErasureReceiptKey(stubInterface shim.ChaincodeStubInterface, eventUUID string, participantUUID string) (string, error) {
+	return stubInterface.CreateCompositeKey("erasure", []string{eventUUID, participantUUID})
+}
+
+func (r *ErasureReceipt) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
+	key, err := MakeErasureReceiptKey(stubInterface, r.EventUUID, r.ParticipantUUID)
+	if err != nil {
+		return err
+	}
+
+	r.DocType = DOC_TYPE_ERASURE_RECEIPT
+	r.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_ERASURE_RECEIPT)
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(key, b)
+}
+
+// CanErase reports whether the client may erase the participant data.
+// the participant itself and the creator of the event are allowed.
+func (e *Event) CanErase(participant *Participant, clientID string) bool {
+	if clientID == "" {
+		return false
+	}
+	return clientID == participant.ParticipateTx.ClientID || clientID == e.EventCreateTx.ClientID
+}
+
+func (e *Event) findParticipant(participantUUID string) *Participant {
+	for idx := range e.Participants {
+		if e.Participants[idx].UUID == participantUUID {
+			return &e.Participants[idx]
+		}
+	}
+	return nil
+}
+
+// EraseParticipant removes the PII of the participant in the event and in the prize results.
+func (e *Event) EraseParticipant(participantUUID string) error {
+	participant := e.findParticipant(participantUUID)
+	if participant == nil {
+		return ErrParticipantNotFound.WithDetail(participantUUID)
+	}
+	if participant.Erased {
+		return ErrAlreadyErased
+	}
+
+	participant.erase()
+	erasePrizeParticipant(e.Prizes, participantUUID)
+	return nil
+}
+
+// EraseParticipant removes the PII of the participant in the prize results of the round.
+func (r *Round) EraseParticipant(participantUUID string) {
+	erasePrizeParticipant(r.Prizes, participantUUID)
+}
+
+func erasePrizeParticipant(prizes []Prize, participantUUID string) {
+	for i := range prizes {
+		prize := &prizes[i]
+		for idx := range prize.Winners {
+			if prize.Winners[idx].UUID == participantUUID {
+				prize.Winners[idx].erase()
+			}
+		}
+		for idx := range prize.Alternates {
+			if prize.Alternates[idx].UUID == participantUUID {
+				prize.Alternates[idx].erase()
+			}
+		}
+		for idx := range prize.Disqualifications {
+			if prize.Disqualifications[idx].Participant.UUID == participantUUID {
+				prize.Disqualifications[idx].Participant.erase()
+			}
+		}
+	}
+}
+
+// erase keeps the UUID and the commitment only.
+func (p *Participant) erase() {
+	p.Information = ""
+	p.AuthInformation = ""
+	p.Erased = true
+}
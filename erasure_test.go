@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEraseParticipantData(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 2, AlternateNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	for _, uuid := range []string{"participant1", "participant2", "participant3", "participant4"} {
+		m.setClient(t, uuid)
+		m.setPrivateData(uuid+"@example.com", "")
+		_, ok = m.call(t, "participateTx"+uuid, "participateLotteryEvent", ParticipateLotteryRequest{
+			EventUUID:   event.UUID,
+			Participant: Participant{UUID: uuid},
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+
+	m.setClient(t, "provider")
+	time.Sleep(2 * time.Second)
+	_, ok = m.call(t, "drawTx", "drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "providerHash"})
+	if !ok {
+		t.FailNow()
+	}
+	drawn, _ := LoadEventByUUID(m, event.UUID)
+	winner := drawn.Prizes[0].Winners[0]
+
+	m.setClient(t, "stranger")
+	_, ok = m.call(t, "eraseTx", "eraseParticipantData", EraseParticipantDataRequest{EventUUID: event.UUID, ParticipantUUID: winner.UUID})
+	if ok {
+		t.Fatal("other client must not erase participant data")
+	}
+
+	m.setClient(t, winner.UUID)
+	payload, ok = m.call(t, "eraseTx", "eraseParticipantData", EraseParticipantDataRequest{EventUUID: event.UUID, ParticipantUUID: winner.UUID, Reason: "GDPR"})
+	if !ok {
+		t.FailNow()
+	}
+	receipt := &ErasureReceipt{}
+	unmarshalData(payload, receipt)
+	if receipt.Commitment != winner.Commitment || receipt.EraseTx.ID != "eraseTx" {
+		t.Errorf("unexpected receipt : %+v", receipt)
+	}
+
+	key, _ := MakeParticipantKey(m, event.UUID, winner.UUID)
+	if _, exist := m.PvtState[DEFAULT_PII_COLLECTION][key]; exist {
+		t.Error("private data must be deleted")
+	}
+
+	erased, _ := LoadEventByUUID(m, event.UUID)
+	participant := erased.findParticipant(winner.UUID)
+	if !participant.Erased || participant.Commitment != winner.Commitment {
+		t.Errorf("unexpected erased participant : %+v", participant)
+	}
+	if !erased.Prizes[0].Winners[0].Erased {
+		t.Error("winner copy must be erased")
+	}
+
+	result := erased.Verify("")
+	if !result.Verified {
+		t.Errorf("draw must be verified after erasure : %s", result.Mismatch)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+type ErrorCode string
+
+// LotteryError is an entry of the error catalog. Code and Name are stable, Message is for humans.
+type LotteryError struct {
+	Code    ErrorCode `json:"code"`
+	Name    string    `json:"name"`
+	Message string    `json:"message"`
+	Field   string    `json:"field,omitempty"` // offending request field
+
+	Violations []FieldViolation `json:"violations,omitempty"` // every offending field of ErrInvalidRequest
+}
+
+func (e *LotteryError) Error() string {
+	return string(e.Code) + " " + e.Name + " : " + e.Message
+}
+
+// WithField returns a copy of the error with the offending request field.
+func (e *LotteryError) WithField(field string) *LotteryError {
+	copied := *e
+	copied.Field = field
+	return &copied
+}
+
+// WithDetail returns a copy of the error with the detail appended to the message.
+func (e *LotteryError) WithDetail(detail string) *LotteryError {
+	copied := *e
+	copied.Message = e.Message + " : " + detail
+	return &copied
+}
+
+// WithViolations returns a copy of the error with the field violations.
+func (e *LotteryError) WithViolations(violations []FieldViolation) *LotteryError {
+	copied := *e
+	copied.Violations = violations
+	return &copied
+}
+
+// Is reports whether err is the catalog entry, regardless of field and detail.
+func (e *LotteryError) Is(err error) bool {
+	lotteryErr, ok := err.(*LotteryError)
+	return ok && lotteryErr.Code == e.Code
+}
+
+func newLotteryError(code ErrorCode, name string, message string) *LotteryError {
+	return &LotteryError{Code: code, Name: name, Message: message}
+}
+
+// lottery rules
+var (
+	ErrDeadlinePassed           = newLotteryError("LOT-001", "DEADLINE_PASSED", "participation is closed")
+	ErrDeadlineNotPassed        = newLotteryError("LOT-002", "DEADLINE_NOT_PASSED", "deadline is not passed")
+	ErrParticipantLimitExceeded = newLotteryError("LOT-003", "PARTICIPANT_LIMIT_EXCEEDED", "this event has exceeded the number of participants")
+	ErrDuplicateParticipant     = newLotteryError("LOT-004", "DUPLICATE_PARTICIPANT", "duplicate participate")
+	ErrInvalidStatus            = newLotteryError("LOT-005", "INVALID_STATUS", "status does not allow the operation")
+	ErrNotWinner                = newLotteryError("LOT-006", "NOT_WINNER", "participant is not a winner of this prize")
+	ErrClaimNotPending          = newLotteryError("LOT-007", "CLAIM_NOT_PENDING", "claim is not pending")
+	ErrClaimDeadlinePassed      = newLotteryError("LOT-008", "CLAIM_DEADLINE_PASSED", "claim deadline is passed")
+	ErrAlreadyErased            = newLotteryError("LOT-009", "ALREADY_ERASED", "participant data is already erased")
+	ErrExcludedPreviousWinner   = newLotteryError("LOT-010", ERR_EXCLUDED_PREVIOUS_WINNER, "participant is excluded as a previous winner")
+	ErrExcludedCooldown         = newLotteryError("LOT-011", ERR_EXCLUDED_COOLDOWN, "participant is excluded during the cooldown")
+	ErrNoMoreOccurrence         = newLotteryError("LOT-012", "NO_MORE_OCCURRENCE", "template has no more occurrence")
+	ErrAlreadyWithdrawn         = newLotteryError("LOT-013", "ALREADY_WITHDRAWN", "participant is already withdrawn")
+	ErrTokenTransfer            = newLotteryError("LOT-014", "TOKEN_TRANSFER_FAILED", "token chaincode rejected the transfer")
+	ErrNotEligible              = newLotteryError("LOT-015", "NOT_ELIGIBLE", "participant is not eligible for the event")
+	ErrNotAllowlisted           = newLotteryError("LOT-016", "NOT_ALLOWLISTED", "participant is not proven in the allowlist of the event")
+)
+
+// request args
+var (
+	ErrInvalidArgsNum  = newLotteryError("LOT-100", "INVALID_ARGS_NUM", "request args num is not matched")
+	ErrInvalidArgsJSON = newLotteryError("LOT-101", "INVALID_ARGS_JSON", "request args is not match request object")
+	ErrRequiredArg     = newLotteryError("LOT-102", "REQUIRED_ARG", "required arg is not exist")
+	ErrInvalidArg      = newLotteryError("LOT-103", "INVALID_ARG", "request arg is not valid")
+	ErrUnknownFunction = newLotteryError("LOT-104", "UNKNOWN_FUNCTION", "request function is not defined")
+	ErrInvalidRequest  = newLotteryError("LOT-105", "INVALID_REQUEST", "request fields are not valid")
+)
+
+// not found
+var (
+	ErrEventNotFound       = newLotteryError("LOT-200", "EVENT_NOT_FOUND", "cannot find event")
+	ErrParticipantNotFound = newLotteryError("LOT-201", "PARTICIPANT_NOT_FOUND", "cannot find participant")
+	ErrPrizeNotFound       = newLotteryError("LOT-202", "PRIZE_NOT_FOUND", "cannot find prize")
+	ErrRoundNotFound       = newLotteryError("LOT-203", "ROUND_NOT_FOUND", "cannot find round")
+	ErrTemplateNotFound    = newLotteryError("LOT-204", "TEMPLATE_NOT_FOUND", "cannot find template")
+	ErrPrivateDataNotFound = newLotteryError("LOT-205", "PRIVATE_DATA_NOT_FOUND", "cannot find private data")
+)
+
+// authorization
+var (
+	ErrUnauthorized = newLotteryError("LOT-300", "UNAUTHORIZED", "client is not allowed to do the operation")
+)
+
+// ErrUndefinedArg is returned for undefined enum values in the request.
+var ErrUndefinedArg = ErrInvalidArg.WithDetail("request args is not defined")
+
+// ErrRequired returns ErrRequiredArg of the field.
+func ErrRequired(field string) *LotteryError {
+	return ErrRequiredArg.WithField(field).WithDetail(field)
+}
+
+// ErrInternal wraps errors out of the catalog, such as ledger failures.
+var ErrInternal = newLotteryError("LOT-900", "INTERNAL", "internal error")
+
+// ErrUnsupportedSchemaVersion is returned for records written by a newer chaincode.
+var ErrUnsupportedSchemaVersion = newLotteryError("LOT-901", "UNSUPPORTED_SCHEMA_VERSION", "record schema version is not supported")
+
+// ToLotteryError returns the catalog entry of the error.
+func ToLotteryError(err error) *LotteryError {
+	switch e := err.(type) {
+	case *LotteryError:
+		return e
+	case *ExclusionError:
+		if e.Code == ERR_EXCLUDED_COOLDOWN {
+			return ErrExcludedCooldown.WithDetail(e.Error())
+		}
+		return ErrExcludedPreviousWinner.WithDetail(e.Error())
+	}
+	return &LotteryError{Code: ErrInternal.Code, Name: ErrInternal.Name, Message: err.Error()}
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-	"github.com/rs/xid"
-	"time"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/sslab-archive/block_lottery_cc/draw"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Status string
@@ -15,6 +18,7 @@ type DrawType string
 
 const (
 	STATUS_REGISTERD Status = "REGISTERED"
+	STATUS_DRAWING   Status = "DRAWING" // batched draw begun, the seed is fixed and the winners are not written yet
 	STATUS_DRAWN     Status = "DRAWN"
 	STATUS_REMOVED   Status = "REMOVED"
 )
@@ -26,11 +30,28 @@ const (
 )
 
 type Prize struct {
-	UUID      string        `json:"UUID"`
-	Title     string        `json:"title"`
-	Memo      string        `json:"memo"`
-	WinnerNum int64         `json:"winnerNum"`
-	Winners   []Participant `json:"winners"`
+	UUID         string        `json:"UUID" metadata:",optional"`
+	Title        string        `json:"title" metadata:",optional"`
+	Memo         string        `json:"memo" metadata:",optional"`
+	WinnerNum    int64         `json:"winnerNum"`
+	Winners      []Participant `json:"winners" metadata:",optional"`
+	AlternateNum int64         `json:"alternateNum" metadata:",optional"` // number of waitlisted participants
+	Alternates   []Participant `json:"alternates" metadata:",optional"`   // ordered, taken from the rest of the shuffle
+
+	Payout int64 `json:"payout" metadata:",optional"` // tokens paid to each winner from the escrow of the event
+
+	ClaimDeadline int64   `json:"claimDeadline" metadata:",optional"` // UNIX timestamp, 0 means winners can claim at any time
+	Claims        []Claim `json:"claims" metadata:",optional"`
+
+	Disqualifications []Disqualification `json:"disqualifications" metadata:",optional"`
+}
+
+// Disqualification records a winner removed after the draw and the alternate promoted in its place.
+type Disqualification struct {
+	Participant  Participant `json:"participant"`
+	Reason       string      `json:"reason" metadata:",optional"`
+	ReplacedBy   string      `json:"replacedBy" metadata:",optional"` // promoted alternate UUID, empty if no alternate was left
+	DisqualifyTx Transaction `json:"disqualifyTx" metadata:",optional"`
 }
 type EventKeyInfo struct {
 	UUID       string `json:"UUID"`
@@ -38,17 +59,33 @@ type EventKeyInfo struct {
 }
 
 type Event struct {
+	DocType       DocType `json:"docType"`
+	SchemaVersion int64   `json:"schemaVersion"`
+
 	// event data
-	UUID           string        `json:"UUID"`
-	EventName      string        `json:"eventName"` // must be UUID
-	Status         Status        `json:"status"`
-	Contents       string        `json:"contents"`
-	CreateTime     int64         `json:"createTime"`     // create timestamp
-	DeadlineTime   int64         `json:"deadlineTime"`   // UNIX timestamp
-	MaxParticipant int64         `json:"maxParticipant"` // Max number of members
-	Participants   []Participant `json:"participants"`
-	DrawTypes      []DrawType    `json:"drawTypes"`
-	Prizes         []Prize       `json:"prizes"`
+	UUID           string          `json:"UUID"`
+	EventName      string          `json:"eventName"` // must be UUID
+	Status         Status          `json:"status"`
+	Contents       string          `json:"contents"`
+	CreateTime     int64           `json:"createTime"`     // create timestamp
+	DeadlineTime   int64           `json:"deadlineTime"`   // UNIX timestamp
+	MaxParticipant int64           `json:"maxParticipant"` // Max number of members
+	ParticipantNum int64           `json:"participantNum"` // number of participants, -1 if not counted yet
+	OrdinalIndexed bool            `json:"ordinalIndexed"` // participants are indexed and drawn in their ordinal order
+	Participants   []Participant   `json:"participants"`
+	DrawTypes      []DrawType      `json:"drawTypes"`
+	Prizes         []Prize         `json:"prizes"`
+	ExclusionRules []ExclusionRule `json:"exclusionRules"`
+
+	EligibilityRules []EligibilityRule `json:"eligibilityRules"`
+
+	// running hash of the participations and withdrawals, fixed by beginDraw
+	ParticipantsHash string `json:"participantsHash"`
+
+	// merkle root of the invitees, empty if anyone can join
+	AllowlistRoot string        `json:"allowlistRoot"`
+	AllowlistLeaf AllowlistLeaf `json:"allowlistLeaf"`
+	AllowlistTx   Transaction   `json:"allowlistTx"` // last rotation of the root
 
 	// block hash
 	TargetBlock BlockInfo `json:"targetBlock"`
@@ -57,21 +94,43 @@ type Event struct {
 	AuthURL    string   `json:"authURL"`
 	AuthParams []string `json:"authParams"`
 
+	// private data collection of participant PII
+	PrivateCollection string `json:"privateCollection"`
+
 	// service provider hash
 	ServiceProviderHash string `json:"serviceProviderHash"`
 
 	// result data
 	SeedHash string `json:"seedHash"`
 
+	// number of staged draw rounds
+	RoundNum int64 `json:"roundNum"`
+
+	// template info, empty if the event is not spawned from a template
+	TemplateUUID       string `json:"templateUUID"`
+	TemplateVersion    int64  `json:"templateVersion"`
+	TemplateOccurrence int64  `json:"templateOccurrence"`
+
+	// entry fee and prize pool, the token chaincode is empty if the event is free
+	Escrow TokenEscrow `json:"escrow"`
+
 	// transaction info
 	EventCreateTx Transaction `json:"eventCreateTx"`
 	DrawTx        Transaction `json:"drawTx"`
+	RemoveTx      Transaction `json:"removeTx"`
 }
 
 func (e *Event) GetKey() string {
 	return MakeKeyByUUID(e.UUID)
 }
 
+func (e *Event) GetPrivateCollection() string {
+	if e.PrivateCollection == "" {
+		return DEFAULT_PII_COLLECTION
+	}
+	return e.PrivateCollection
+}
+
 func (e *Event) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
 	b, err := e.ToLedgerBinary()
 	if err != nil {
@@ -88,9 +147,17 @@ func (e *Event) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
 		}
 	}
 
+	// keep the winners index up to date with disqualifications
+	if e.Status == STATUS_DRAWN {
+		err = saveWinnerIndex(stubInterface, e.UUID, -1, e.EventCreateTx.ClientID, e.Prizes, e.DrawTx.Timestamp)
+		if err != nil {
+			return err
+		}
+	}
+
 	// save participants if participant data is changed
 	for _, participant := range e.Participants {
-		key, err := stubInterface.CreateCompositeKey(e.GetKey(), []string{"participants", participant.UUID})
+		key, err := MakeParticipantKey(stubInterface, e.UUID, participant.UUID)
 		if err != nil {
 			return err
 		}
@@ -100,96 +167,325 @@ func (e *Event) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
 			return err
 		}
 
+		b, err := participant.ToLedgerBinary()
+		if err != nil {
+			return err
+		}
+
 		// val == nil -> new data
-		// err != nil && recordedData != participant -> data is changed
-		recordedData := &Participant{}
-		if err := json.Unmarshal(val, recordedData); (err != nil && *recordedData != participant) || val == nil {
-			b, err := participant.ToLedgerBinary()
+		// b != val -> data is changed
+		if bytes.Compare(b, val) != 0 {
+			err = stubInterface.PutState(key, b)
 			if err != nil {
 				return err
 			}
 
-			err = stubInterface.PutState(key, b)
-			if err != nil {
-				return err
+			// new participant -> index the event by participant, and the participant by ordinal
+			if val == nil {
+				err = saveParticipantIndex(stubInterface, participant.UUID, e.UUID)
+				if err != nil {
+					return err
+				}
+				if e.OrdinalIndexed {
+					err = saveOrdinalIndex(stubInterface, e.UUID, participant)
+					if err != nil {
+						return err
+					}
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// saveParticipantIndex records the event in composite key ( participantEvents~{participantUUID}~{eventUUID} )
+func saveParticipantIndex(stubInterface shim.ChaincodeStubInterface, participantUUID string, eventUUID string) error {
+	key, err := stubInterface.CreateCompositeKey("participantEvents", []string{participantUUID, eventUUID})
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(key, []byte{0x00})
+}
+
+// MakeOrdinalKey makes the key of the participant at the ordinal ( event_{eventUUID}~ordinals~{ordinal} ).
+// ordinals are zero padded, so the keys are in ordinal order.
+func MakeOrdinalKey(stubInterface shim.ChaincodeStubInterface, eventUUID string, ordinal int64) (string, error) {
+	return stubInterface.CreateCompositeKey(MakeKeyByUUID(eventUUID), []string{"ordinals", fmt.Sprintf("%012d", ordinal)})
+}
+
+// saveWithdrawal moves the participant taking the ordinal of a withdrawn one, and removes the freed last ordinal.
+// lastOrdinal is the ordinal of the last participant before the withdrawal.
+func saveWithdrawal(stubInterface shim.ChaincodeStubInterface, eventUUID string, moved *Participant, lastOrdinal int64) error {
+	if moved != nil {
+		err := saveOrdinalIndex(stubInterface, eventUUID, *moved)
+		if err != nil {
+			return err
+		}
+	}
+	key, err := MakeOrdinalKey(stubInterface, eventUUID, lastOrdinal)
+	if err != nil {
+		return err
+	}
+	return stubInterface.DelState(key)
+}
+
+// saveOrdinalIndex records the participant UUID at the ordinal of the participant.
+func saveOrdinalIndex(stubInterface shim.ChaincodeStubInterface, eventUUID string, participant Participant) error {
+	key, err := MakeOrdinalKey(stubInterface, eventUUID, participant.Ordinal)
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(key, []byte(participant.UUID))
+}
+
 // it will remove participants data in event data.
 // participants data will be record in composite key ( event_{tx timestamp}_{eventUUID}~participants~{participantsUUID} )
 func (e Event) ToLedgerBinary() ([]byte, error) {
+	e.DocType = DOC_TYPE_EVENT
+	e.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_EVENT)
 	e.Participants = nil
 	return json.Marshal(e)
 }
 
 func (e *Event) Participate(participant Participant, txTimestamp int64) error {
-	if int64(len(e.Participants)) >= e.MaxParticipant {
-		return errors.New("this event has exceeded the number of participants")
+	if e.Status != STATUS_REGISTERD {
+		return ErrInvalidStatus.WithDetail("status is not registered. check is removed or already drawn")
+	}
+
+	activeNum := int64(len(e.activeParticipants()))
+	if activeNum >= e.MaxParticipant {
+		return ErrParticipantLimitExceeded
 	}
 
 	if txTimestamp > e.DeadlineTime {
-		return errors.New("participation is closed")
+		return ErrDeadlinePassed
 	}
 
 	if e.containParticipant(participant.UUID) {
-		return errors.New("duplicate participate")
+		return ErrDuplicateParticipant
 	}
 
+	participant.Ordinal = -1
+	if e.OrdinalIndexed {
+		participant.Ordinal = activeNum
+	}
 	e.Participants = append(e.Participants, participant)
+	e.ParticipantNum = activeNum + 1
+	e.chainParticipantsHash("participate", &participant)
+	return nil
+}
+
+// chainParticipantsHash folds the participation or the withdrawal of the participant into the running hash of the participants.
+func (e *Event) chainParticipantsHash(action string, participant *Participant) {
+	hash := sha256.Sum256([]byte(e.ParticipantsHash + "_" + action + "_" + participant.UUID + "_" + participant.Commitment))
+	e.ParticipantsHash = hex.EncodeToString(hash[:])
+}
+
+// Withdraw marks the participant withdrawn, only by the participant itself and before the deadline.
+// the last participant in the ordinal order takes the ordinal of the withdrawn one,
+// so the drawing ordinals stay 0 .. ParticipantNum-1. it returns the moved participant, nil if none is moved.
+func (e *Event) Withdraw(participantUUID string, tx Transaction) (*Participant, error) {
+	if e.Status != STATUS_REGISTERD {
+		return nil, ErrInvalidStatus.WithDetail("status is not registered")
+	}
+	if tx.Timestamp > e.DeadlineTime {
+		return nil, ErrDeadlinePassed
+	}
+	if !e.OrdinalIndexed {
+		return nil, ErrInvalidStatus.WithDetail("participants are not indexed by ordinal. migrate the event first")
+	}
+
+	participant := e.findParticipant(participantUUID)
+	if participant == nil {
+		return nil, ErrParticipantNotFound.WithDetail(participantUUID)
+	}
+	if participant.Withdrawn {
+		return nil, ErrAlreadyWithdrawn
+	}
+	if tx.ClientID == "" || tx.ClientID != participant.ParticipateTx.ClientID {
+		return nil, ErrUnauthorized.WithDetail("only the participant can withdraw")
+	}
+
+	lastOrdinal := int64(len(e.activeParticipants())) - 1
+	var moved *Participant
+	for idx := range e.Participants {
+		if !e.Participants[idx].Withdrawn && e.Participants[idx].Ordinal == lastOrdinal && e.Participants[idx].UUID != participantUUID {
+			moved = &e.Participants[idx]
+			moved.Ordinal = participant.Ordinal
+		}
+	}
+
+	participant.Withdrawn = true
+	participant.Ordinal = -1
+	participant.WithdrawTx = tx
+	e.ParticipantNum = lastOrdinal
+	e.chainParticipantsHash("withdraw", participant)
+	if moved == nil {
+		return nil, nil
+	}
+	result := *moved
+	return &result, nil
+}
+
+// Remove closes the event before it is drawn. participants cannot join or be drawn afterwards.
+func (e *Event) Remove(tx Transaction) error {
+	if e.Status != STATUS_REGISTERD && e.Status != STATUS_DRAWING {
+		return ErrInvalidStatus.WithDetail("status is not registered. check is removed or already drawn")
+	}
+	if tx.ClientID == "" || tx.ClientID != e.EventCreateTx.ClientID {
+		return ErrUnauthorized.WithDetail("only the event creator can remove the event")
+	}
+
+	e.Status = STATUS_REMOVED
+	e.RemoveTx = tx
 	return nil
 }
 
+// Draw draws the event over the participants in memory. DrawStreaming draws the same result from the ledger.
 func (e *Event) Draw(tx Transaction) error {
+	err := e.startDraw(tx)
+	if err != nil {
+		return err
+	}
+
+	drawPrizes(e.Prizes, e.drawingParticipants(), e.SeedHash, tx)
+	return nil
+}
+
+// startDraw checks the event can be drawn and fixes the seed.
+func (e *Event) startDraw(tx Transaction) error {
 	if tx.Timestamp < e.DeadlineTime {
-		return errors.New("deadline is not passed")
+		return ErrDeadlineNotPassed
 	}
 
 	if e.Status != STATUS_REGISTERD {
-		return errors.New("status is not registered. check is removed or already drawn")
+		return ErrInvalidStatus.WithDetail("status is not registered. check is removed or already drawn")
 	}
 	e.Status = STATUS_DRAWN
-	concatSeed := e.TargetBlock.Hash + "_PLUS_" + e.ServiceProviderHash
-	e.SeedHash = concatSeed
+	e.SeedHash = MakeSeed(e.TargetBlock.Hash, e.ServiceProviderHash)
+	return nil
+}
 
-	var usingParticipants []Participant
+// drawingParticipants returns participants taking part in the draw, capped at MaxParticipant.
+func (e *Event) drawingParticipants() []Participant {
+	participants := e.activeParticipants()
+	if int64(len(participants)) > e.MaxParticipant {
+		return participants[0:e.MaxParticipant]
+	}
+	return participants
+}
 
-	if int64(len(e.Participants)) > e.MaxParticipant {
-		usingParticipants = e.Participants[0:e.MaxParticipant]
-	} else {
-		usingParticipants = e.Participants
+// activeParticipants returns participants not withdrawn, in the draw order.
+func (e *Event) activeParticipants() []Participant {
+	participants := make([]Participant, 0, len(e.Participants))
+	for _, participant := range e.Participants {
+		if !participant.Withdrawn {
+			participants = append(participants, participant)
+		}
+	}
+	return participants
+}
+
+// MakeSeed concatenates the seed inputs of a draw.
+func MakeSeed(blockHash string, serviceProviderHash string) string {
+	return draw.MakeSeed(blockHash, serviceProviderHash)
+}
+
+// drawPrizes shuffles the participants with the seed, assigns winners and alternates, and opens the claims.
+func drawPrizes(prizes []Prize, participants []Participant, seed string, tx Transaction) {
+	awardPrizes(prizes, FisherYatesShuffle(participants, seed), tx)
+}
+
+// awardPrizes assigns the shuffled participants and opens the claims.
+// the shuffle may be cut after the prize slots, the rest of it is never assigned.
+func awardPrizes(prizes []Prize, shuffledParticipant []Participant, tx Transaction) {
+	assignPrizes(prizes, shuffledParticipant)
+	for idx := range prizes {
+		prizes[idx].openClaims(tx)
 	}
-	shuffledParticipant := FisherYatesShuffle(usingParticipants, concatSeed)
+}
 
+// assignPrizes hands out the shuffled participants in prize order ( draw.Assign ).
+// winners of every prize are taken first, and the rest of the shuffle fills the alternates.
+func assignPrizes(prizes []Prize, shuffledParticipant []Participant) {
+	slots := make([]draw.PrizeSlots, len(prizes))
 	totalPrizeNum := int64(0)
-	for _, prize := range e.Prizes {
+	for idx, prize := range prizes {
+		slots[idx] = draw.PrizeSlots{WinnerNum: prize.WinnerNum, AlternateNum: prize.AlternateNum}
 		totalPrizeNum += prize.WinnerNum
 	}
 	logger.Debug("total winner num : " + strconv.FormatInt(totalPrizeNum, 10))
 
-	// # of participant < # of winner
+	// # of participant < # of winner -> later prizes get fewer or no winners
 	if totalPrizeNum > int64(len(shuffledParticipant)) {
 		logger.Debug("winner is too big...")
-		participantIdx := 0
-		for idx := range e.Prizes {
-			e.Prizes[idx].Winners = make([]Participant, 0)
-			if int64(len(shuffledParticipant)-participantIdx) >= e.Prizes[idx].WinnerNum {
-				e.Prizes[idx].Winners = shuffledParticipant[participantIdx : participantIdx+int(e.Prizes[idx].WinnerNum)]
-				participantIdx += int(e.Prizes[idx].WinnerNum)
-			} else {
-				for i := participantIdx; i < len(shuffledParticipant); i++ {
-					e.Prizes[idx].Winners = append(e.Prizes[idx].Winners, shuffledParticipant[i])
-				}
-				break
-			}
+	}
+
+	for idx, assignment := range draw.Assign(len(shuffledParticipant), slots) {
+		prizes[idx].Winners = pickParticipants(shuffledParticipant, assignment.Winners)
+		prizes[idx].Alternates = pickParticipants(shuffledParticipant, assignment.Alternates)
+	}
+}
+
+// pickParticipants copies the participants at the shuffled positions.
+func pickParticipants(shuffledParticipant []Participant, positions []int) []Participant {
+	picked := make([]Participant, 0, len(positions))
+	for _, position := range positions {
+		picked = append(picked, shuffledParticipant[position])
+	}
+	return picked
+}
+
+// DisqualifyWinner removes a winner of the prize and promotes the first alternate into the same position.
+func (e *Event) DisqualifyWinner(prizeUUID string, participantUUID string, reason string, tx Transaction) error {
+	if e.Status != STATUS_DRAWN {
+		return ErrInvalidStatus.WithDetail("status is not drawn")
+	}
+
+	prize := e.findPrize(prizeUUID)
+	if prize == nil {
+		return ErrPrizeNotFound.WithDetail(prizeUUID)
+	}
+
+	return prize.disqualifyWinner(participantUUID, reason, e.DrawTx, tx)
+}
+
+func (p *Prize) disqualifyWinner(participantUUID string, reason string, drawTx Transaction, tx Transaction) error {
+	winnerIdx := -1
+	for idx, winner := range p.Winners {
+		if winner.UUID == participantUUID {
+			winnerIdx = idx
+			break
 		}
+	}
+	if winnerIdx < 0 {
+		return ErrNotWinner
+	}
+
+	disqualification := Disqualification{
+		Participant:  p.Winners[winnerIdx],
+		Reason:       reason,
+		DisqualifyTx: tx,
+	}
+
+	p.removeClaim(participantUUID)
+	if len(p.Alternates) > 0 {
+		p.Winners[winnerIdx] = p.Alternates[0]
+		p.Alternates = p.Alternates[1:]
+		disqualification.ReplacedBy = p.Winners[winnerIdx].UUID
+		p.Claims = append(p.Claims, p.newPendingClaim(disqualification.ReplacedBy, drawTx, tx))
 	} else {
-		passedIdx := 0
-		for idx := range e.Prizes {
-			e.Prizes[idx].Winners = shuffledParticipant[passedIdx : passedIdx+int(e.Prizes[idx].WinnerNum)]
-			passedIdx += int(e.Prizes[idx].WinnerNum)
+		p.Winners = append(p.Winners[:winnerIdx], p.Winners[winnerIdx+1:]...)
+	}
+
+	p.Disqualifications = append(p.Disqualifications, disqualification)
+	return nil
+}
+
+func (e *Event) findPrize(prizeUUID string) *Prize {
+	for idx := range e.Prizes {
+		if e.Prizes[idx].UUID == prizeUUID {
+			return &e.Prizes[idx]
 		}
 	}
 	return nil
@@ -205,29 +501,51 @@ func (e *Event) containParticipant(participantUUID string) bool {
 }
 
 func NewEvent(request *CreateLotteryRequest, createEventTX Transaction) Event {
-	requestPrize := request.Prizes
-	for idx := range requestPrize {
-		requestPrize[idx].UUID = xid.New().String()
-	}
+	// the prize pool is checked by checkCreateLotteryArgs
+	pool, _ := prizePool(request.Prizes)
 	return Event{
-		UUID:                xid.New().String(),
+		DocType:             DOC_TYPE_EVENT,
+		SchemaVersion:       CurrentSchemaVersion(DOC_TYPE_EVENT),
+		UUID:                createEventTX.MakeUUID(0),
 		EventName:           request.EventName,
 		Status:              STATUS_REGISTERD,
 		Contents:            request.Contents,
 		CreateTime:          time.Now().Unix(),
 		DeadlineTime:        request.DeadlineTime,
 		MaxParticipant:      request.MaxParticipant,
+		ParticipantNum:      0,
+		OrdinalIndexed:      true,
 		Participants:        make([]Participant, 0),
 		DrawTypes:           request.DrawTypes,
-		Prizes:              requestPrize,
+		ExclusionRules:      request.ExclusionRules,
+		EligibilityRules:    request.EligibilityRules,
+		AllowlistRoot:       strings.ToLower(request.AllowlistRoot),
+		AllowlistLeaf:       allowlistLeafOf(request.AllowlistRoot, request.AllowlistLeaf),
+		Prizes:              newPrizes(request.Prizes, createEventTX),
 		TargetBlock:         request.TargetBlock,
 		AuthURL:             request.AuthURL,
 		AuthParams:          request.AuthParams,
+		PrivateCollection:   request.PrivateCollection,
 		ServiceProviderHash: request.ServiceProviderHash,
 		SeedHash:            "",
-		EventCreateTx:       createEventTX,
-		DrawTx:              Transaction{},
+		Escrow: TokenEscrow{
+			TokenChaincode: request.TokenChaincode,
+			TokenChannel:   request.TokenChannel,
+			EntryFee:       request.EntryFee,
+			PrizePool:      pool,
+		},
+		EventCreateTx: createEventTX,
+		DrawTx:        Transaction{},
+		RemoveTx:      Transaction{},
+	}
+}
+
+// newPrizes gives a new UUID to every requested prize, following the UUID of the event or the round created by the transaction.
+func newPrizes(requestPrize []Prize, tx Transaction) []Prize {
+	for idx := range requestPrize {
+		requestPrize[idx].UUID = tx.MakeUUID(idx + 1)
 	}
+	return requestPrize
 }
 
 func MakeKeyByUUID(UUID string) string {
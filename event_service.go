@@ -1,66 +1,197 @@
 package main
 
 import (
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	DEFAULT_PAGE_SIZE int32 = 100
+	MAX_PAGE_SIZE     int32 = 1000
 )
 
+// EventPage is a page of a list query.
+// Bookmark is passed to the next request to fetch the next page.
+type EventPage struct {
+	Events       []Event `json:"events"`
+	Bookmark     string  `json:"bookmark"`
+	FetchedCount int32   `json:"fetchedCount"`
+}
+
+type RoundPage struct {
+	Rounds       []Round `json:"rounds"`
+	Bookmark     string  `json:"bookmark"`
+	FetchedCount int32   `json:"fetchedCount"`
+}
+
 func LoadEventByUUID(stubInterface shim.ChaincodeStubInterface, UUID string) (*Event, error) {
 	event := &Event{}
 	b, err := stubInterface.GetState(MakeKeyByUUID(UUID))
+	if err != nil {
+		return nil, err
+	}
 	if b == nil {
 		return &Event{}, nil
 	}
+
+	err = UnmarshalRecord(DOC_TYPE_EVENT, b, event)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(b, event)
+	err = loadParticipants(stubInterface, event)
 	if err != nil {
 		return nil, err
 	}
+	return event, nil
+}
 
-	// load participant data
+// loadParticipants loads participant data recorded in composite keys of the event.
+func loadParticipants(stubInterface shim.ChaincodeStubInterface, event *Event) error {
 	event.Participants = make([]Participant, 0)
 	participantIterator, err := stubInterface.GetStateByPartialCompositeKey(event.GetKey(), []string{"participants"})
-	defer participantIterator.Close()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer participantIterator.Close()
 
 	for participantIterator.HasNext() {
 		b, err := participantIterator.Next()
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		p := &Participant{}
-		err = json.Unmarshal(b.Value, p)
+		err = UnmarshalRecord(DOC_TYPE_PARTICIPANT, b.Value, p)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		event.Participants = append(event.Participants, *p)
 	}
-	return event, nil
-}
+	event.ParticipantNum = int64(len(event.activeParticipants()))
 
-func LoadEventByDateRange(stubInterface shim.ChaincodeStubInterface, startDate int64, endDate int64) ([]Event, error) {
+	// participants are drawn in the ordinal order once indexed, in the key order before
+	if event.OrdinalIndexed {
+		sort.SliceStable(event.Participants, func(i, j int) bool {
+			return event.Participants[i].Ordinal < event.Participants[j].Ordinal
+		})
+	}
+	return nil
+}
 
-	eventIter, err := stubInterface.GetQueryResult(fmt.Sprintf(`{
+func LoadEventByDateRange(stubInterface shim.ChaincodeStubInterface, startDate int64, endDate int64, page PageRequest) (*EventPage, error) {
+	eventIter, metadata, err := stubInterface.GetQueryResultWithPagination(fmt.Sprintf(`{
    "selector": {
+      "docType": "%s",
       "createTime": {
          "$gte": %d,
          "$lte": %d
       }
    }
-}`, startDate, endDate))
-	defer eventIter.Close()
+}`, DOC_TYPE_EVENT, startDate, endDate), page.GetPageSize(), page.Bookmark)
 	if err != nil {
 		return nil, err
 	}
 
+	return loadEventPage(stubInterface, eventIter, metadata, page.ExcludeParticipants)
+}
+
+// LoadEventBySearch loads events matched with the search filters.
+func LoadEventBySearch(stubInterface shim.ChaincodeStubInterface, request *SearchLotteryRequest) (*EventPage, error) {
+	query, err := MakeSearchQuery(request)
+	if err != nil {
+		return nil, err
+	}
+
+	eventIter, metadata, err := stubInterface.GetQueryResultWithPagination(query, request.GetPageSize(), request.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadEventPage(stubInterface, eventIter, metadata, request.ExcludeParticipants)
+}
+
+// MakeSearchQuery makes a CouchDB query of the search filters.
+// the sort fields follow the shipped indexes in META-INF/statedb/couchdb/indexes
+func MakeSearchQuery(request *SearchLotteryRequest) (string, error) {
+	selector := map[string]interface{}{
+		"docType": DOC_TYPE_EVENT,
+	}
+	sortFields := []string{"docType"}
+
+	if request.Status != "" {
+		selector["status"] = request.Status
+		sortFields = append(sortFields, "status")
+	}
+	if request.DrawType != "" {
+		selector["drawTypes"] = map[string]interface{}{"$elemMatch": map[string]interface{}{"$eq": request.DrawType}}
+	}
+	if request.BlockType != "" {
+		selector["targetBlock.blockType"] = request.BlockType
+	}
+	if request.SubmitterID != "" {
+		selector["eventCreateTx.submitterId"] = request.SubmitterID
+	}
+	if request.DeadlineFrom != 0 || request.DeadlineTo != 0 {
+		deadline := map[string]interface{}{}
+		if request.DeadlineFrom != 0 {
+			deadline["$gte"] = request.DeadlineFrom
+		}
+		if request.DeadlineTo != 0 {
+			deadline["$lte"] = request.DeadlineTo
+		}
+		selector["deadlineTime"] = deadline
+	}
+	if request.PrizeTitle != "" {
+		selector["prizes"] = map[string]interface{}{
+			"$elemMatch": map[string]interface{}{
+				"title": map[string]interface{}{"$regex": regexp.QuoteMeta(request.PrizeTitle)},
+			},
+		}
+	}
+
+	switch request.SortBy {
+	case "", "createTime":
+		sortFields = append(sortFields, "createTime")
+	case "deadlineTime":
+		sortFields = append(sortFields, "deadlineTime")
+	default:
+		return "", ErrInvalidArg.WithField("sortBy").WithDetail("cannot sort by " + request.SortBy)
+	}
+
+	sortOrder := request.SortOrder
+	switch sortOrder {
+	case "":
+		sortOrder = "asc"
+	case "asc", "desc":
+	default:
+		return "", ErrInvalidArg.WithField("sortOrder").WithDetail("unknown sort order " + request.SortOrder)
+	}
+
+	sort := make([]map[string]string, 0)
+	for _, field := range sortFields {
+		sort = append(sort, map[string]string{field: sortOrder})
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"selector": selector,
+		"sort":     sort,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func loadEventPage(stubInterface shim.ChaincodeStubInterface, eventIter shim.StateQueryIteratorInterface, metadata *pb.QueryResponseMetadata, excludeParticipants bool) (*EventPage, error) {
+	defer eventIter.Close()
+
 	events := make([]Event, 0)
 	for eventIter.HasNext() {
 		kv, err := eventIter.Next()
@@ -69,41 +200,123 @@ func LoadEventByDateRange(stubInterface shim.ChaincodeStubInterface, startDate i
 		}
 
 		event := &Event{}
-		err = json.Unmarshal(kv.Value, event)
-
-		if err != nil {
-			return nil, err
-		}
-		event.Participants = make([]Participant, 0)
-
-		participantIterator, err := stubInterface.GetStateByPartialCompositeKey(event.GetKey(), []string{"participants"})
+		err = UnmarshalRecord(DOC_TYPE_EVENT, kv.Value, event)
 		if err != nil {
-			participantIterator.Close()
 			return nil, err
 		}
 
-		for participantIterator.HasNext() {
-			b, err := participantIterator.Next()
+		event.Participants = make([]Participant, 0)
+		if !excludeParticipants {
+			err = loadParticipants(stubInterface, event)
 			if err != nil {
-				participantIterator.Close()
 				return nil, err
 			}
+		}
 
-			p := &Participant{}
-			err = json.Unmarshal(b.Value, p)
-			if err != nil {
-				participantIterator.Close()
-				return nil, err
-			}
+		events = append(events, *event)
+	}
+
+	return &EventPage{
+		Events:       events,
+		Bookmark:     metadata.Bookmark,
+		FetchedCount: metadata.FetchedRecordsCount,
+	}, nil
+}
 
-			event.Participants = append(event.Participants, *p)
+// LoadEventByParticipantUUID loads events the participant joined, using the participant index
+// recorded in composite key ( participantEvents~{participantUUID}~{eventUUID} )
+func LoadEventByParticipantUUID(stubInterface shim.ChaincodeStubInterface, participantUUID string, page PageRequest) (*EventPage, error) {
+	indexIter, metadata, err := stubInterface.GetStateByPartialCompositeKeyWithPagination(
+		"participantEvents",
+		[]string{participantUUID},
+		page.GetPageSize(),
+		page.Bookmark,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer indexIter.Close()
+
+	events := make([]Event, 0)
+	for indexIter.HasNext() {
+		kv, err := indexIter.Next()
+		if err != nil {
+			return nil, err
 		}
 
+		_, attributes, err := stubInterface.SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, err
+		}
 
+		event, err := loadEventForList(stubInterface, attributes[1], page.ExcludeParticipants)
+		if err != nil {
+			return nil, err
+		}
+		if event.UUID == "" {
+			continue
+		}
 		events = append(events, *event)
-		participantIterator.Close()
+	}
+
+	return &EventPage{
+		Events:       events,
+		Bookmark:     metadata.Bookmark,
+		FetchedCount: metadata.FetchedRecordsCount,
+	}, nil
+}
 
+func loadEventForList(stubInterface shim.ChaincodeStubInterface, UUID string, excludeParticipants bool) (*Event, error) {
+	if !excludeParticipants {
+		return LoadEventByUUID(stubInterface, UUID)
 	}
 
-	return events, nil
+	b, err := stubInterface.GetState(MakeKeyByUUID(UUID))
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{}
+	if b == nil {
+		return event, nil
+	}
+	err = UnmarshalRecord(DOC_TYPE_EVENT, b, event)
+	if err != nil {
+		return nil, err
+	}
+	event.Participants = make([]Participant, 0)
+	return event, nil
+}
+
+// LoadRoundPage loads a page of rounds of the event.
+func LoadRoundPage(stubInterface shim.ChaincodeStubInterface, eventUUID string, page PageRequest) (*RoundPage, error) {
+	roundIterator, metadata, err := stubInterface.GetStateByPartialCompositeKeyWithPagination(
+		MakeKeyByUUID(eventUUID),
+		[]string{"rounds"},
+		page.GetPageSize(),
+		page.Bookmark,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer roundIterator.Close()
+
+	rounds := make([]Round, 0)
+	for roundIterator.HasNext() {
+		kv, err := roundIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		round := &Round{}
+		err = UnmarshalRecord(DOC_TYPE_ROUND, kv.Value, round)
+		if err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, *round)
+	}
+	return &RoundPage{
+		Rounds:       rounds,
+		Bookmark:     metadata.Bookmark,
+		FetchedCount: metadata.FetchedRecordsCount,
+	}, nil
 }
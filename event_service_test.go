@@ -1,16 +1,16 @@
 package main
 
 import (
-	"testing"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"testing"
 )
 
 func TestLoadEventByDateRange(t *testing.T) {
 	l := new(LotteryChaincode)
-	m := shim.NewMockStub("lottery_cc",l)
+	m := shimtest.NewMockStub("lottery_cc", l)
 	m.MockTransactionStart("c5a2e0e7231216c9483c170d03e955bee90d41b8262841ebda5545f5a3eab73e")
-	re := l.createLotteryEvent(m,[]string{"",`{
+	re := l.createLotteryEvent(m, []string{"", `{
     "UUID": "blj5u1aotin61uf67t90",
     "eventName": "test event name3",
     "status": "REGISTERED",
@@ -54,4 +54,27 @@ func TestLoadEventByDateRange(t *testing.T) {
 	fmt.Printf("return : %s", re.Payload)
 
 	fmt.Println(m.State)
-}
\ No newline at end of file
+}
+
+func TestMakeSearchQuery(t *testing.T) {
+	query, err := MakeSearchQuery(&SearchLotteryRequest{
+		Status:     STATUS_REGISTERD,
+		DrawType:   DRAW_BLOCK_HASH,
+		PrizeTitle: "iphone (64GB)",
+		SortBy:     "deadlineTime",
+		SortOrder:  "desc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"selector":{"docType":"event","drawTypes":{"$elemMatch":{"$eq":"DRAW_BLOCK_HASH"}},"prizes":{"$elemMatch":{"title":{"$regex":"iphone \\(64GB\\)"}}},"status":"REGISTERED"},"sort":[{"docType":"desc"},{"status":"desc"},{"deadlineTime":"desc"}]}`
+	if query != expected {
+		t.Errorf("unexpected query : %s", query)
+	}
+
+	_, err = MakeSearchQuery(&SearchLotteryRequest{SortBy: "contents"})
+	if err == nil {
+		t.Error("sort by not indexed field must fail")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func newDrawTestEvent(participantNum int) *Event {
+	event := &Event{
+		UUID:           "testEvent",
+		Status:         STATUS_REGISTERD,
+		DeadlineTime:   100,
+		MaxParticipant: 100,
+		Participants:   make([]Participant, 0),
+		DrawTypes:      []DrawType{DRAW_BLOCK_HASH},
+		Prizes: []Prize{
+			{UUID: "prize1", WinnerNum: 1, AlternateNum: 2},
+			{UUID: "prize2", WinnerNum: 2, AlternateNum: 1},
+		},
+		TargetBlock: BlockInfo{BlockType: BITCOIN, Hash: "blockHash", Height: 1},
+	}
+	for i := 0; i < participantNum; i++ {
+		event.Participants = append(event.Participants, Participant{UUID: "p" + strconv.Itoa(i)})
+	}
+	return event
+}
+
+func TestEventDrawAlternates(t *testing.T) {
+	event := newDrawTestEvent(10)
+	err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shuffled := FisherYatesShuffle(event.Participants, event.SeedHash)
+	expected := [][]Participant{
+		shuffled[0:1], shuffled[1:3], // winners
+		shuffled[3:5], shuffled[5:6], // alternates
+	}
+	actual := [][]Participant{
+		event.Prizes[0].Winners, event.Prizes[1].Winners,
+		event.Prizes[0].Alternates, event.Prizes[1].Alternates,
+	}
+	for i := range expected {
+		if len(expected[i]) != len(actual[i]) {
+			t.Fatalf("group %d: expected %d participants, got %d", i, len(expected[i]), len(actual[i]))
+		}
+		for j := range expected[i] {
+			if expected[i][j].UUID != actual[i][j].UUID {
+				t.Errorf("group %d position %d: expected %s, got %s", i, j, expected[i][j].UUID, actual[i][j].UUID)
+			}
+		}
+	}
+}
+
+func TestEventDrawNotEnoughParticipants(t *testing.T) {
+	event := newDrawTestEvent(2)
+	err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(event.Prizes[0].Winners) != 1 || len(event.Prizes[1].Winners) != 1 {
+		t.Errorf("unexpected winner count : %d, %d", len(event.Prizes[0].Winners), len(event.Prizes[1].Winners))
+	}
+	if len(event.Prizes[0].Alternates) != 0 || len(event.Prizes[1].Alternates) != 0 {
+		t.Error("alternates must be empty when every participant already won")
+	}
+}
+
+func TestEventDisqualifyWinner(t *testing.T) {
+	event := newDrawTestEvent(10)
+	err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prize := &event.Prizes[0]
+	disqualified := prize.Winners[0]
+	firstAlternate := prize.Alternates[0]
+	secondAlternate := prize.Alternates[1]
+
+	err = event.DisqualifyWinner("prize1", disqualified.UUID, "ineligible", Transaction{ID: "disqualifyTx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prize.Winners[0].UUID != firstAlternate.UUID {
+		t.Errorf("expected promoted alternate %s, got %s", firstAlternate.UUID, prize.Winners[0].UUID)
+	}
+	if len(prize.Alternates) != 1 || prize.Alternates[0].UUID != secondAlternate.UUID {
+		t.Error("promoted alternate must be removed from the waitlist")
+	}
+	if len(prize.Disqualifications) != 1 || prize.Disqualifications[0].ReplacedBy != firstAlternate.UUID {
+		t.Error("disqualification is not recorded")
+	}
+
+	err = event.DisqualifyWinner("prize1", disqualified.UUID, "ineligible", Transaction{ID: "disqualifyTx2"})
+	if err == nil {
+		t.Error("disqualified participant must not be disqualified twice")
+	}
+}
+
+// saveDrawnEvent records a drawn event of 10 participants, created by the provider client.
+func saveDrawnEvent(t *testing.T, m *identityStub) *Event {
+	m.setClient(t, "provider")
+	event := newDrawTestEvent(10)
+	event.EventCreateTx = Transaction{ID: "createTx", ClientID: GetClientID(m)}
+	if err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200}); err != nil {
+		t.Fatal(err)
+	}
+	m.MockTransactionStart("drawTx")
+	defer m.MockTransactionEnd("drawTx")
+	if err := event.SaveToLedger(m); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestDisqualifyWinnerAuthorization(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	event := saveDrawnEvent(t, m)
+	request := DisqualifyWinnerRequest{
+		EventUUID:       event.UUID,
+		PrizeUUID:       "prize2",
+		ParticipantUUID: event.Prizes[1].Winners[0].UUID,
+		Reason:          "ineligible",
+	}
+
+	m.setClient(t, event.Prizes[1].Winners[1].UUID)
+	if _, ok := m.call(t, "disqualifyTx", "disqualifyWinner", request); ok {
+		t.Fatal("participant disqualified another winner")
+	}
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	if _, ok := m.call(t, "disqualifyTx", "disqualifyWinner", request); !ok {
+		t.Fatal("administrator is rejected")
+	}
+	m.setClient(t, "provider")
+	request.ParticipantUUID = event.Prizes[1].Winners[1].UUID
+	if _, ok := m.call(t, "disqualifyTx2", "disqualifyWinner", request); !ok {
+		t.Fatal("event creator is rejected")
+	}
+}
+
+func TestEventWithdraw(t *testing.T) {
+	event := newDrawTestEvent(0)
+	event.MaxParticipant = 3
+	event.OrdinalIndexed = true
+	for _, uuid := range []string{"p0", "p1", "p2"} {
+		if err := event.Participate(Participant{UUID: uuid, ParticipateTx: Transaction{ClientID: uuid}}, 50); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := event.Withdraw("p0", Transaction{ClientID: "p1", Timestamp: 60}); !ErrUnauthorized.Is(err) {
+		t.Fatal("other client withdrew the participant")
+	}
+	if _, err := event.Withdraw("p0", Transaction{ClientID: "p0", Timestamp: 101}); !ErrDeadlinePassed.Is(err) {
+		t.Fatal("participant withdrew after the deadline")
+	}
+
+	// the last participant takes the freed ordinal
+	moved, err := event.Withdraw("p0", Transaction{ClientID: "p0", Timestamp: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved == nil || moved.UUID != "p2" || moved.Ordinal != 0 || event.ParticipantNum != 2 {
+		t.Fatal("last participant is not moved into the freed ordinal")
+	}
+	if withdrawn := event.findParticipant("p0"); !withdrawn.Withdrawn || withdrawn.Ordinal != -1 {
+		t.Fatal("participant record is not marked withdrawn")
+	}
+	if _, err = event.Withdraw("p0", Transaction{ClientID: "p0", Timestamp: 60}); !ErrAlreadyWithdrawn.Is(err) {
+		t.Fatal("participant withdrew twice")
+	}
+
+	// withdrawn participants leave the capacity and the draw, and do not join again
+	if err = event.Participate(Participant{UUID: "p0"}, 70); !ErrDuplicateParticipant.Is(err) {
+		t.Fatal("withdrawn participant joined again")
+	}
+	if err = event.Participate(Participant{UUID: "p3"}, 70); err != nil {
+		t.Fatal(err)
+	}
+	if event.findParticipant("p3").Ordinal != 2 {
+		t.Fatal("new participant does not take the next ordinal")
+	}
+	for _, participant := range event.drawingParticipants() {
+		if participant.UUID == "p0" {
+			t.Fatal("withdrawn participant is drawn")
+		}
+	}
+	if len(event.drawingParticipants()) != 3 {
+		t.Fatal("capacity is not freed")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+type ExclusionType string
+
+const (
+	EXCLUDE_PREVIOUS_WINNERS ExclusionType = "EXCLUDE_PREVIOUS_WINNERS" // anyone who has ever won
+	EXCLUDE_COOLDOWN         ExclusionType = "EXCLUDE_COOLDOWN"         // anyone who won within PeriodSeconds
+)
+
+const (
+	ERR_EXCLUDED_PREVIOUS_WINNER = "EXCLUDED_PREVIOUS_WINNER"
+	ERR_EXCLUDED_COOLDOWN        = "EXCLUDED_COOLDOWN"
+)
+
+// ExclusionRule rejects participants by their wins in other draws, checked at participation time.
+type ExclusionRule struct {
+	Type             ExclusionType `json:"type"`
+	PeriodSeconds    int64         `json:"periodSeconds" metadata:",optional"`    // cooldown period of EXCLUDE_COOLDOWN
+	SameProviderOnly bool          `json:"sameProviderOnly" metadata:",optional"` // count only wins in events created by the same client
+}
+
+// WinRecord is an entry of the winners index.
+// it is recorded in composite key ( winner~{participantUUID}~{eventUUID}~{roundIndex}~{prizeUUID} )
+type WinRecord struct {
+	DocType         DocType `json:"docType"`
+	SchemaVersion   int64   `json:"schemaVersion"`
+	ParticipantUUID string  `json:"participantUUID"`
+	EventUUID       string  `json:"eventUUID"`
+	RoundIndex      int64   `json:"roundIndex"` // -1 for the event draw
+	PrizeUUID       string  `json:"prizeUUID"`
+	ProviderID      string  `json:"providerID"` // client ID of the event creator
+	DrawTimestamp   int64   `json:"drawTimestamp"`
+}
+
+// ExclusionError is returned when a participant is rejected by an exclusion rule.
+type ExclusionError struct {
+	Code      string        `json:"code"`
+	Rule      ExclusionRule `json:"rule"`
+	WinRecord WinRecord     `json:"winRecord"`
+}
+
+func (e *ExclusionError) Error() string {
+	return fmt.Sprintf("%s : participant won event %s at %d", e.Code, e.WinRecord.EventUUID, e.WinRecord.DrawTimestamp)
+}
+
+func makeWinRecordKey(stubInterface shim.ChaincodeStubInterface, record WinRecord) (string, error) {
+	return stubInterface.CreateCompositeKey("winner", []string{
+		record.ParticipantUUID,
+		record.EventUUID,
+		strconv.FormatInt(record.RoundIndex, 10),
+		record.PrizeUUID,
+	})
+}
+
+// saveWinnerIndex records current winners of the prizes and removes disqualified winners from the index.
+func saveWinnerIndex(stubInterface shim.ChaincodeStubInterface, eventUUID string, roundIndex int64, providerID string, prizes []Prize, drawTimestamp int64) error {
+	for _, prize := range prizes {
+		record := WinRecord{
+			DocType:       DOC_TYPE_WIN_RECORD,
+			SchemaVersion: CurrentSchemaVersion(DOC_TYPE_WIN_RECORD),
+			EventUUID:     eventUUID,
+			RoundIndex:    roundIndex,
+			PrizeUUID:     prize.UUID,
+			ProviderID:    providerID,
+			DrawTimestamp: drawTimestamp,
+		}
+
+		for _, disqualification := range prize.Disqualifications {
+			record.ParticipantUUID = disqualification.Participant.UUID
+			key, err := makeWinRecordKey(stubInterface, record)
+			if err != nil {
+				return err
+			}
+			err = stubInterface.DelState(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, winner := range prize.Winners {
+			record.ParticipantUUID = winner.UUID
+			key, err := makeWinRecordKey(stubInterface, record)
+			if err != nil {
+				return err
+			}
+
+			val, err := stubInterface.GetState(key)
+			if err != nil {
+				return err
+			}
+			if val != nil {
+				continue
+			}
+
+			b, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			err = stubInterface.PutState(key, b)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func LoadWinRecords(stubInterface shim.ChaincodeStubInterface, participantUUID string) ([]WinRecord, error) {
+	recordIterator, err := stubInterface.GetStateByPartialCompositeKey("winner", []string{participantUUID})
+	if err != nil {
+		return nil, err
+	}
+	defer recordIterator.Close()
+
+	records := make([]WinRecord, 0)
+	for recordIterator.HasNext() {
+		kv, err := recordIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		record := &WinRecord{}
+		err = UnmarshalRecord(DOC_TYPE_WIN_RECORD, kv.Value, record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+// CheckExclusionRules checks the participant against the exclusion rules of the event.
+// it returns *ExclusionError if the participant is rejected.
+func (e *Event) CheckExclusionRules(stubInterface shim.ChaincodeStubInterface, participantUUID string, txTimestamp int64) error {
+	if len(e.ExclusionRules) == 0 {
+		return nil
+	}
+
+	records, err := LoadWinRecords(stubInterface, participantUUID)
+	if err != nil {
+		return err
+	}
+	return e.checkExclusionRules(records, txTimestamp)
+}
+
+func (e *Event) checkExclusionRules(records []WinRecord, txTimestamp int64) error {
+	for _, rule := range e.ExclusionRules {
+		for _, record := range records {
+			if rule.SameProviderOnly && record.ProviderID != e.EventCreateTx.ClientID {
+				continue
+			}
+
+			switch rule.Type {
+			case EXCLUDE_PREVIOUS_WINNERS:
+				return &ExclusionError{Code: ERR_EXCLUDED_PREVIOUS_WINNER, Rule: rule, WinRecord: record}
+			case EXCLUDE_COOLDOWN:
+				if txTimestamp-record.DrawTimestamp < rule.PeriodSeconds {
+					return &ExclusionError{Code: ERR_EXCLUDED_COOLDOWN, Rule: rule, WinRecord: record}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func TestCheckExclusionRules(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+	m.MockTransactionStart("drawTx")
+
+	prizes := []Prize{{UUID: "prize1", Winners: []Participant{{UUID: "winner"}, {UUID: "disqualified"}}}}
+	err := saveWinnerIndex(m, "previousEvent", -1, "x509::CN=provider1::CN=ca", prizes, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// disqualified winner is removed from the index
+	prizes[0].Winners = prizes[0].Winners[:1]
+	prizes[0].Disqualifications = []Disqualification{{Participant: Participant{UUID: "disqualified"}}}
+	err = saveWinnerIndex(m, "previousEvent", -1, "x509::CN=provider1::CN=ca", prizes, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.MockTransactionEnd("drawTx")
+
+	// wins are counted by the client ID of the creator, not by the submitter ID it claims
+	event := &Event{
+		UUID:          "event",
+		EventCreateTx: Transaction{SubmitterID: "SERVICE_PROVIDER_2", ClientID: "x509::CN=provider1::CN=ca"},
+		ExclusionRules: []ExclusionRule{
+			{Type: EXCLUDE_COOLDOWN, PeriodSeconds: 30 * 86400, SameProviderOnly: true},
+		},
+	}
+
+	err = event.CheckExclusionRules(m, "winner", 1000+86400)
+	if exclusionErr, ok := err.(*ExclusionError); !ok || exclusionErr.Code != ERR_EXCLUDED_COOLDOWN {
+		t.Errorf("expected %s, got %v", ERR_EXCLUDED_COOLDOWN, err)
+	}
+
+	err = event.CheckExclusionRules(m, "winner", 1000+31*86400)
+	if err != nil {
+		t.Errorf("cooldown is over, got %v", err)
+	}
+
+	err = event.CheckExclusionRules(m, "disqualified", 1000+86400)
+	if err != nil {
+		t.Errorf("disqualified participant is not a winner, got %v", err)
+	}
+
+	event.EventCreateTx.ClientID = "x509::CN=provider2::CN=ca"
+	err = event.CheckExclusionRules(m, "winner", 1000+86400)
+	if err != nil {
+		t.Errorf("win of other provider must not count, got %v", err)
+	}
+
+	event.ExclusionRules = []ExclusionRule{{Type: EXCLUDE_PREVIOUS_WINNERS}}
+	err = event.CheckExclusionRules(m, "winner", 1000+365*86400)
+	if exclusionErr, ok := err.(*ExclusionError); !ok || exclusionErr.Code != ERR_EXCLUDED_PREVIOUS_WINNER {
+		t.Errorf("expected %s, got %v", ERR_EXCLUDED_PREVIOUS_WINNER, err)
+	}
+}
@@ -1,17 +1,10 @@
 module github.com/sslab-archive/block_lottery_cc
 
+go 1.14
+
 require (
-	github.com/fsouza/go-dockerclient v1.4.2 // indirect
-	github.com/golang/protobuf v1.3.2 // indirect
-	github.com/hyperledger/fabric v1.4.2
-	github.com/hyperledger/fabric-amcl v0.0.0-20181230093703-5ccba6eab8d6 // indirect
-	github.com/miekg/pkcs11 v1.0.3 // indirect
-	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7 // indirect
-	github.com/rs/xid v1.2.1
-	github.com/spf13/viper v1.4.0 // indirect
-	github.com/sykesm/zap-logfmt v0.0.2 // indirect
-	golang.org/x/crypto v0.0.0-20190820162420-60c769a6c586 // indirect
-	golang.org/x/net v0.0.0-20190813141303-74dc4d7220e7 // indirect
-	google.golang.org/genproto v0.0.0-20180831171423-11092d34479b // indirect
-	google.golang.org/grpc v1.23.0 // indirect
+	github.com/golang/protobuf v1.3.2
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200424173110-d7076418f212
+	github.com/hyperledger/fabric-contract-api-go v1.1.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
 )
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// HistoryEntry is a version of a ledger key.
+type HistoryEntry struct {
+	Key       string          `json:"key"`
+	TxID      string          `json:"txID"`
+	Timestamp int64           `json:"timestamp"`
+	IsDelete  bool            `json:"isDelete"`
+	Value     json.RawMessage `json:"value"`
+	Diff      []FieldChange   `json:"diff"` // changes from the previous version of the same key
+}
+
+// FieldChange is a changed field between two versions. nested fields are joined with "."
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+type EventHistory struct {
+	EventUUID string         `json:"eventUUID"`
+	Timeline  []HistoryEntry `json:"timeline"` // ordered by timestamp
+}
+
+// LoadEventHistory loads the history of the event key and its participant keys.
+func LoadEventHistory(stubInterface shim.ChaincodeStubInterface, event *Event) (*EventHistory, error) {
+	keys := []string{event.GetKey()}
+	for _, participant := range event.Participants {
+		key, err := MakeParticipantKey(stubInterface, event.UUID, participant.UUID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	timeline := make([]HistoryEntry, 0)
+	for _, key := range keys {
+		entries, err := loadKeyHistory(stubInterface, key)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, entries...)
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp < timeline[j].Timestamp
+	})
+
+	return &EventHistory{
+		EventUUID: event.UUID,
+		Timeline:  timeline,
+	}, nil
+}
+
+func loadKeyHistory(stubInterface shim.ChaincodeStubInterface, key string) ([]HistoryEntry, error) {
+	historyIterator, err := stubInterface.GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer historyIterator.Close()
+
+	entries := make([]HistoryEntry, 0)
+	var previous json.RawMessage
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := HistoryEntry{
+			Key:      key,
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = modification.Timestamp.Seconds
+		}
+		if !modification.IsDelete && json.Valid(modification.Value) {
+			entry.Value = json.RawMessage(modification.Value)
+		}
+
+		entry.Diff = DiffJSON(previous, entry.Value)
+		previous = entry.Value
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// DiffJSON compares two JSON documents field by field.
+// objects are compared recursively, other values including arrays are compared as a whole.
+func DiffJSON(before json.RawMessage, after json.RawMessage) []FieldChange {
+	changes := make([]FieldChange, 0)
+	diffJSON("", before, after, &changes)
+	return changes
+}
+
+func diffJSON(field string, before json.RawMessage, after json.RawMessage, changes *[]FieldChange) {
+	beforeObject := map[string]json.RawMessage{}
+	afterObject := map[string]json.RawMessage{}
+	beforeErr := unmarshalObject(before, &beforeObject)
+	afterErr := unmarshalObject(after, &afterObject)
+
+	if beforeErr != nil || afterErr != nil || beforeObject == nil || afterObject == nil {
+		if !jsonEqual(before, after) {
+			*changes = append(*changes, FieldChange{Field: field, Before: before, After: after})
+		}
+		return
+	}
+
+	names := make([]string, 0)
+	for name := range beforeObject {
+		names = append(names, name)
+	}
+	for name := range afterObject {
+		if _, exist := beforeObject[name]; !exist {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childField := name
+		if field != "" {
+			childField = field + "." + name
+		}
+		diffJSON(childField, beforeObject[name], afterObject[name], changes)
+	}
+}
+
+// unmarshalObject reads a JSON object. missing value is read as an empty object.
+func unmarshalObject(data json.RawMessage, object *map[string]json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, object)
+}
+
+func jsonEqual(a json.RawMessage, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	before := json.RawMessage(`{"status":"REGISTERED","targetBlock":{"hash":"","height":592122},"prizes":[{"title":"prize"}]}`)
+	after := json.RawMessage(`{"status":"DRAWN","targetBlock":{"hash":"blockHash","height":592122},"prizes":[{"title":"prize"}],"seedHash":"seed"}`)
+
+	changes := DiffJSON(before, after)
+	expected := []FieldChange{
+		{Field: "seedHash", Before: nil, After: json.RawMessage(`"seed"`)},
+		{Field: "status", Before: json.RawMessage(`"REGISTERED"`), After: json.RawMessage(`"DRAWN"`)},
+		{Field: "targetBlock.hash", Before: json.RawMessage(`""`), After: json.RawMessage(`"blockHash"`)},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for idx := range expected {
+		if changes[idx].Field != expected[idx].Field ||
+			!jsonEqual(changes[idx].Before, expected[idx].Before) ||
+			!jsonEqual(changes[idx].After, expected[idx].After) {
+			t.Errorf("expected %+v, got %+v", expected[idx], changes[idx])
+		}
+	}
+
+	// first version lists every field, deleted version lists every removed field
+	if len(DiffJSON(nil, before)) != 4 {
+		t.Errorf("unexpected first version diff : %+v", DiffJSON(nil, before))
+	}
+	if len(DiffJSON(before, nil)) != 4 {
+		t.Errorf("unexpected delete diff : %+v", DiffJSON(before, nil))
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Logger writes leveled chaincode logs. the level is read from CORE_CHAINCODE_LOGGING_LEVEL like the peer does.
+type Logger struct {
+	name  string
+	debug bool
+}
+
+func NewLogger(name string) *Logger {
+	return &Logger{
+		name:  name,
+		debug: strings.ToUpper(os.Getenv("CORE_CHAINCODE_LOGGING_LEVEL")) == "DEBUG",
+	}
+}
+
+func (l *Logger) Debug(args ...interface{}) {
+	if l.debug {
+		l.print("DEBU", args)
+	}
+}
+
+func (l *Logger) Info(args ...interface{}) {
+	l.print("INFO", args)
+}
+
+func (l *Logger) Error(args ...interface{}) {
+	l.print("ERRO", args)
+}
+
+func (l *Logger) print(level string, args []interface{}) {
+	log.Print(level + " [" + l.name + "] " + fmt.Sprint(args...))
+}
@@ -0,0 +1,114 @@
+// Package merkle builds sha256 merkle trees over ordered leaves, and proves the inclusion of a leaf.
+// leaves and nodes are hashed with different prefixes, and an odd node is carried up unchanged.
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ALGORITHM is the version of the tree construction.
+const ALGORITHM = "sha256-prefixed/1"
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// HashLeaf returns the leaf hash of the data.
+func HashLeaf(data []byte) []byte {
+	hash := sha256.Sum256(append([]byte{leafPrefix}, data...))
+	return hash[:]
+}
+
+func hashNode(left []byte, right []byte) []byte {
+	b := make([]byte, 0, 1+len(left)+len(right))
+	b = append(b, nodePrefix)
+	b = append(b, left...)
+	b = append(b, right...)
+	hash := sha256.Sum256(b)
+	return hash[:]
+}
+
+// Root returns the root of the leaf hashes. the root of no leaves is sha256 of nothing.
+func Root(leafHashes [][]byte) []byte {
+	if len(leafHashes) == 0 {
+		hash := sha256.Sum256(nil)
+		return hash[:]
+	}
+
+	level := leafHashes
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// RootHex returns the hex encoded root of the leaf data.
+func RootHex(leaves [][]byte) string {
+	leafHashes := make([][]byte, len(leaves))
+	for idx, leaf := range leaves {
+		leafHashes[idx] = HashLeaf(leaf)
+	}
+	return hex.EncodeToString(Root(leafHashes))
+}
+
+// ProofStep is the sibling of a node on the path from a leaf to the root.
+type ProofStep struct {
+	Hash string `json:"hash"`                      // hex encoded sibling hash
+	Left bool   `json:"left" metadata:",optional"` // the sibling is the left node
+}
+
+// Proof returns the inclusion proof of the leaf at index. levels where the node is carried up have no step.
+func Proof(leafHashes [][]byte, index int) []ProofStep {
+	proof := make([]ProofStep, 0)
+	if index < 0 || index >= len(leafHashes) {
+		return proof
+	}
+
+	level := leafHashes
+	for len(level) > 1 {
+		if index%2 == 1 {
+			proof = append(proof, ProofStep{Hash: hex.EncodeToString(level[index-1]), Left: true})
+		} else if index+1 < len(level) {
+			proof = append(proof, ProofStep{Hash: hex.EncodeToString(level[index+1])})
+		}
+
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		level = next
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyProof reports whether the leaf data is in the tree of the hex encoded root.
+func VerifyProof(leaf []byte, proof []ProofStep, rootHex string) bool {
+	hash := HashLeaf(leaf)
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return false
+		}
+		if step.Left {
+			hash = hashNode(sibling, hash)
+		} else {
+			hash = hashNode(hash, sibling)
+		}
+	}
+	return hex.EncodeToString(hash) == rootHex
+}
@@ -0,0 +1,67 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestRoot(t *testing.T) {
+	a, b, c := HashLeaf([]byte("a")), HashLeaf([]byte("b")), HashLeaf([]byte("c"))
+
+	if !bytes.Equal(Root([][]byte{a}), a) {
+		t.Error("root of a single leaf is the leaf")
+	}
+	// the odd leaf is carried up
+	expected := hashNode(hashNode(a, b), c)
+	if !bytes.Equal(Root([][]byte{a, b, c}), expected) {
+		t.Error("unexpected root of three leaves")
+	}
+	if bytes.Equal(Root([][]byte{a, b, c}), Root([][]byte{b, a, c})) {
+		t.Error("root must depend on the leaf order")
+	}
+
+	empty := sha256.Sum256(nil)
+	if !bytes.Equal(Root(nil), empty[:]) {
+		t.Error("unexpected root of no leaves")
+	}
+
+	// a node is not a leaf
+	if bytes.Equal(HashLeaf(append(a, b...)), hashNode(a, b)) {
+		t.Error("leaf and node hashes must be separated")
+	}
+}
+
+func TestProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := make([][]byte, n)
+		leafHashes := make([][]byte, n)
+		for i := range leaves {
+			leaves[i] = []byte{byte('a' + i)}
+			leafHashes[i] = HashLeaf(leaves[i])
+		}
+		root := RootHex(leaves)
+
+		for i := range leaves {
+			proof := Proof(leafHashes, i)
+			if !VerifyProof(leaves[i], proof, root) {
+				t.Fatalf("%d leaves : proof of leaf %d is not verified", n, i)
+			}
+			if VerifyProof([]byte("z"), proof, root) {
+				t.Fatalf("%d leaves : proof of leaf %d verifies another leaf", n, i)
+			}
+			if len(proof) > 0 {
+				proof[0].Left = !proof[0].Left
+				if VerifyProof(leaves[i], proof, root) {
+					t.Fatalf("%d leaves : proof of leaf %d is verified in the wrong order", n, i)
+				}
+			}
+		}
+	}
+
+	// a node hash is not a leaf
+	a, b := HashLeaf([]byte("a")), HashLeaf([]byte("b"))
+	if VerifyProof(append(a, b...), nil, RootHex([][]byte{[]byte("a"), []byte("b")})) {
+		t.Error("inner node is verified as a leaf")
+	}
+}
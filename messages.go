@@ -1,27 +1,45 @@
 package main
 
+import "github.com/sslab-archive/block_lottery_cc/merkle"
+
 type QueryType string
 
 const (
 	QUERY_BY_EVENT_ID       QueryType = "QUERY_BY_EVENT_ID"
 	QUERY_BY_PARTICIPANT_ID QueryType = "QUERY_BY_PARTICIPANT_ID"
 	QUERY_BY_DATE_RANGE     QueryType = "QUERY_BY_DATE_RANGE"
+
+	QUERY_ROUNDS_BY_EVENT_ID QueryType = "QUERY_ROUNDS_BY_EVENT_ID"
+	QUERY_BY_FILTER          QueryType = "QUERY_BY_FILTER"
 )
 
 type CreateLotteryRequest struct {
-	EventName        string     `json:"eventName"` // must be UUID
-	Contents         string     `json:"contents"`
-	DeadlineTime     int64      `json:"deadlineTime"`   // UNIX timestamp
-	MaxParticipant   int64      `json:"maxParticipant"` // Max number of members
-	DrawTypes        []DrawType `json:"drawTypes"`
-	Prizes           []Prize    `json:"prizes"`
-	SubmitterID      string     `json:"submitterID"`
-	SubmitterAddress string     `json:"submitterAddress"`
-	AuthURL          string     `json:"authURL"`
-	AuthParams       []string   `json:"authParams"`
+	EventName        string            `json:"eventName" metadata:",optional"` // must be UUID
+	Contents         string            `json:"contents" metadata:",optional"`
+	DeadlineTime     int64             `json:"deadlineTime" metadata:",optional"`   // UNIX timestamp
+	MaxParticipant   int64             `json:"maxParticipant" metadata:",optional"` // Max number of members
+	DrawTypes        []DrawType        `json:"drawTypes" metadata:",optional"`
+	Prizes           []Prize           `json:"prizes"`
+	ExclusionRules   []ExclusionRule   `json:"exclusionRules" metadata:",optional"`
+	EligibilityRules []EligibilityRule `json:"eligibilityRules" metadata:",optional"`
+	SubmitterID      string            `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string            `json:"submitterAddress" metadata:",optional"`
+	AuthURL          string            `json:"authURL" metadata:",optional"`
+	AuthParams       []string          `json:"authParams" metadata:",optional"`
+
+	PrivateCollection string `json:"privateCollection" metadata:",optional"` // DEFAULT_PII_COLLECTION if not set
 
-	TargetBlock         BlockInfo `json:"targetBlock"`
-	ServiceProviderHash string    `json:"serviceProviderHash"`
+	TargetBlock         BlockInfo `json:"targetBlock" metadata:",optional"`
+	ServiceProviderHash string    `json:"serviceProviderHash" metadata:",optional"`
+
+	// paid events only. the prize pool, the payouts of every winner, is funded by the creator on creation.
+	TokenChaincode string `json:"tokenChaincode" metadata:",optional"`
+	TokenChannel   string `json:"tokenChannel" metadata:",optional"`
+	EntryFee       int64  `json:"entryFee" metadata:",optional"`
+
+	// invite-only events. AllowlistLeaf is ALLOWLIST_PARTICIPANT_UUID if not set
+	AllowlistRoot string        `json:"allowlistRoot" metadata:",optional"`
+	AllowlistLeaf AllowlistLeaf `json:"allowlistLeaf" metadata:",optional"`
 }
 
 type QueryLotteryRequest struct {
@@ -33,35 +51,271 @@ type QueryLotteryByEventIDRequest struct {
 	EventUUID string `json:"eventUUID"`
 }
 
+// PageRequest is embedded in list queries.
+type PageRequest struct {
+	PageSize            int32  `json:"pageSize" metadata:",optional"` // DEFAULT_PAGE_SIZE if not set
+	Bookmark            string `json:"bookmark" metadata:",optional"` // bookmark returned by the previous page
+	ExcludeParticipants bool   `json:"excludeParticipants" metadata:",optional"`
+}
+
+// GetPageSize returns the requested page size within (0, MAX_PAGE_SIZE].
+func (p PageRequest) GetPageSize() int32 {
+	if p.PageSize <= 0 {
+		return DEFAULT_PAGE_SIZE
+	}
+	if p.PageSize > MAX_PAGE_SIZE {
+		return MAX_PAGE_SIZE
+	}
+	return p.PageSize
+}
+
 type QueryLotteryByParticipantIDRequest struct {
 	QueryLotteryRequest
+	PageRequest
 	ParticipantUUID string `json:"participantUUID"`
 }
 
 type QueryLotteryByDateRangeRequest struct {
 	QueryLotteryRequest
-	StartDateTimestamp int64 `json:"startDateTimestamp"`
-	EndDateTimestamp   int64 `json:"endDateTimestamp"`
+	PageRequest
+	StartDateTimestamp int64 `json:"startDateTimestamp" metadata:",optional"`
+	EndDateTimestamp   int64 `json:"endDateTimestamp" metadata:",optional"`
+}
+
+// SearchLotteryRequest filters events. empty filters are not applied.
+type SearchLotteryRequest struct {
+	QueryLotteryRequest
+	PageRequest
+	Status       Status    `json:"status" metadata:",optional"`
+	DrawType     DrawType  `json:"drawType" metadata:",optional"`
+	BlockType    BlockType `json:"blockType" metadata:",optional"`
+	SubmitterID  string    `json:"submitterID" metadata:",optional"`
+	DeadlineFrom int64     `json:"deadlineFrom" metadata:",optional"` // UNIX timestamp
+	DeadlineTo   int64     `json:"deadlineTo" metadata:",optional"`   // UNIX timestamp
+	PrizeTitle   string    `json:"prizeTitle" metadata:",optional"`   // substring of a prize title
+	SortBy       string    `json:"sortBy" metadata:",optional"`       // createTime (default) or deadlineTime
+	SortOrder    string    `json:"sortOrder" metadata:",optional"`    // asc (default) or desc
 }
 
+type QueryLotteryRoundsRequest struct {
+	QueryLotteryRequest
+	PageRequest
+	EventUUID string `json:"eventUUID"`
+}
+
+// ParticipateLotteryRequest carries participant PII in the transient map ( TRANSIENT_PARTICIPANT_KEY ),
+// the information fields of the participant must be empty.
 type ParticipateLotteryRequest struct {
-	EventUUID   string      `json:"eventUUID"`
-	Participant Participant `json:"participant"`
+	EventUUID      string             `json:"eventUUID"`
+	Participant    Participant        `json:"participant"`
+	AllowlistProof []merkle.ProofStep `json:"allowlistProof" metadata:",optional"` // inclusion proof of invite-only events
 
-	SubmitterID      string `json:"submitterID"`
-	SubmitterAddress string `json:"submitterAddress"`
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
 }
 
 type DrawLotteryRequest struct {
 	EventUUID           string    `json:"eventUUID"`
-	TargetBlock         BlockInfo `json:"targetBlock"`
-	ServiceProviderHash string    `json:"serviceProviderHash"`
+	TargetBlock         BlockInfo `json:"targetBlock" metadata:",optional"`
+	ServiceProviderHash string    `json:"serviceProviderHash" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+// ContinueDrawRequest moves a batched draw by a chunk. the chunk is DEFAULT_DRAW_CHUNK_SIZE if ChunkSize is 0.
+type ContinueDrawRequest struct {
+	EventUUID string `json:"eventUUID"`
+	ChunkSize int64  `json:"chunkSize" metadata:",optional"` // swaps or participants processed in the transaction
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type FinalizeDrawRequest struct {
+	EventUUID string `json:"eventUUID"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type DisqualifyWinnerRequest struct {
+	EventUUID       string `json:"eventUUID"`
+	PrizeUUID       string `json:"prizeUUID"`
+	ParticipantUUID string `json:"participantUUID"`
+	Reason          string `json:"reason"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type ClaimPrizeRequest struct {
+	EventUUID       string `json:"eventUUID"`
+	PrizeUUID       string `json:"prizeUUID"`
+	ParticipantUUID string `json:"participantUUID"`
+	Signature       string `json:"signature" metadata:",optional"` // base64 ECDSA signature, not required when claiming with the participation certificate
 
-	SubmitterID      string `json:"submitterID"`
-	SubmitterAddress string `json:"submitterAddress"`
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type CloseClaimsRequest struct {
+	EventUUID string `json:"eventUUID"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
 }
 
 type VerifyLotteryRequest struct {
 	EventUUID string `json:"eventUUID"`
-	InputHash string `json:"inputHash"`
+	InputHash string `json:"inputHash" metadata:",optional"`
+}
+
+type VerifyLotteryRoundRequest struct {
+	VerifyLotteryRequest
+	RoundIndex int64 `json:"roundIndex" metadata:",optional"`
+}
+
+type AddLotteryRoundRequest struct {
+	EventUUID              string  `json:"eventUUID"`
+	DrawTime               int64   `json:"drawTime"` // UNIX timestamp
+	ExcludePreviousWinners bool    `json:"excludePreviousWinners" metadata:",optional"`
+	Prizes                 []Prize `json:"prizes"`
+
+	TargetBlock         BlockInfo `json:"targetBlock" metadata:",optional"`
+	ServiceProviderHash string    `json:"serviceProviderHash" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type DrawLotteryRoundRequest struct {
+	EventUUID           string    `json:"eventUUID"`
+	RoundIndex          int64     `json:"roundIndex" metadata:",optional"`
+	TargetBlock         BlockInfo `json:"targetBlock" metadata:",optional"`
+	ServiceProviderHash string    `json:"serviceProviderHash" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type CreateLotteryTemplateRequest struct {
+	Name           string          `json:"name" metadata:",optional"`
+	Contents       string          `json:"contents" metadata:",optional"`
+	MaxParticipant int64           `json:"maxParticipant" metadata:",optional"`
+	DrawTypes      []DrawType      `json:"drawTypes" metadata:",optional"`
+	Prizes         []Prize         `json:"prizes"`
+	ExclusionRules []ExclusionRule `json:"exclusionRules" metadata:",optional"`
+	BlockType      BlockType       `json:"blockType" metadata:",optional"`
+	AuthURL        string          `json:"authURL" metadata:",optional"`
+	AuthParams     []string        `json:"authParams" metadata:",optional"`
+	Recurrence     RecurrenceRule  `json:"recurrence"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type UpdateLotteryTemplateRequest struct {
+	CreateLotteryTemplateRequest
+	TemplateUUID string `json:"templateUUID"`
+}
+
+type QueryParticipantPrivateDataRequest struct {
+	EventUUID       string `json:"eventUUID"`
+	ParticipantUUID string `json:"participantUUID"`
+}
+
+// BulkParticipateRequest adds participants in a transaction, listed in Participants without PII,
+// or in the participant file uploaded with uploadBulkChunk as FileHash and ChunkNum.
+type BulkParticipateRequest struct {
+	EventUUID    string        `json:"eventUUID"`
+	Participants []Participant `json:"participants" metadata:",optional"`
+	FileHash     string        `json:"fileHash" metadata:",optional"` // hex SHA-256 of the uploaded file, a JSON array of participants
+	ChunkNum     int32         `json:"chunkNum" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+// UploadBulkChunkRequest uploads a chunk of a participant file, committed by the hash of the whole file.
+// the chunk is taken from the transient map ( bulkChunk ), so the PII is not in the transaction.
+type UploadBulkChunkRequest struct {
+	EventUUID  string `json:"eventUUID"`
+	FileHash   string `json:"fileHash"`
+	ChunkIndex int32  `json:"chunkIndex"`
+	Data       string `json:"data" metadata:",optional"` // rejected, the chunk is sent in the transient map
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type SetLotteryConfigRequest struct {
+	MaxBulkRows      int64 `json:"maxBulkRows"`
+	MaxBulkChunkSize int64 `json:"maxBulkChunkSize"`
+	MaxBulkChunks    int64 `json:"maxBulkChunks"`
+	MaxParticipant   int64 `json:"maxParticipant" metadata:",optional"` // DEFAULT_MAX_PARTICIPANT if not set
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type WithdrawParticipationRequest struct {
+	EventUUID       string `json:"eventUUID"`
+	ParticipantUUID string `json:"participantUUID"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+// UpdateAllowlistRequest rotates the allowlist of a registered event. an empty root removes the allowlist.
+type UpdateAllowlistRequest struct {
+	EventUUID     string        `json:"eventUUID"`
+	AllowlistRoot string        `json:"allowlistRoot" metadata:",optional"`
+	AllowlistLeaf AllowlistLeaf `json:"allowlistLeaf" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type RemoveLotteryRequest struct {
+	EventUUID string `json:"eventUUID"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type EraseParticipantDataRequest struct {
+	EventUUID       string `json:"eventUUID"`
+	ParticipantUUID string `json:"participantUUID"`
+	Reason          string `json:"reason" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+type GetDrawCertificateRequest struct {
+	EventUUID string `json:"eventUUID"`
+}
+
+type QueryLotteryHistoryRequest struct {
+	EventUUID string `json:"eventUUID"`
+}
+
+type QueryLotteryTemplateRequest struct {
+	TemplateUUID string `json:"templateUUID"`
+	Version      int64  `json:"version" metadata:",optional"` // 0 means the current version
+}
+
+type SpawnFromTemplateRequest struct {
+	TemplateUUID        string `json:"templateUUID"`
+	ServiceProviderHash string `json:"serviceProviderHash" metadata:",optional"`
+
+	SubmitterID      string `json:"submitterID" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+}
+
+// MigrateEventsRequest asks for a batch of the event migration. the batch starts from the first event if Bookmark is empty.
+type MigrateEventsRequest struct {
+	PageSize int32  `json:"pageSize"`                      // events in the batch, a batch rewrites every record of its events
+	Bookmark string `json:"bookmark" metadata:",optional"` // bookmark returned by the previous batch
 }
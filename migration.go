@@ -0,0 +1,185 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// MigrationResult is the result of a migrateEvents batch.
+type MigrationResult struct {
+	Scanned  int32    `json:"scanned"`  // events read in the batch
+	Migrated []string `json:"migrated"` // UUIDs of events with rewritten records
+	Bookmark string   `json:"bookmark"` // UUID of the next event, empty when every event is scanned
+}
+
+// MigrateEvents rewrites a batch of events and their records in the current schema versions.
+// the ledger does not page range queries in update transactions, so the batch is cut here
+// and the bookmark is the UUID the next batch starts from.
+func MigrateEvents(stubInterface shim.ChaincodeStubInterface, pageSize int32, bookmark string) (*MigrationResult, error) {
+	prefix := MakeKeyByUUID("")
+	eventIter, err := stubInterface.GetStateByRange(prefix+bookmark, prefix+string(utf8.MaxRune))
+	if err != nil {
+		return nil, err
+	}
+	defer eventIter.Close()
+
+	result := &MigrationResult{Migrated: make([]string, 0)}
+	for eventIter.HasNext() {
+		kv, err := eventIter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		UUID := strings.TrimPrefix(kv.Key, prefix)
+		if result.Scanned == pageSize {
+			result.Bookmark = UUID
+			break
+		}
+		result.Scanned++
+
+		migrated, err := MigrateEvent(stubInterface, UUID)
+		if err != nil {
+			return nil, err
+		}
+		if migrated {
+			result.Migrated = append(result.Migrated, UUID)
+		}
+	}
+	return result, nil
+}
+
+// MigrateEvent rewrites the event, its participants and its rounds if any of them is not in the current schema version.
+// participant PII left in the world state by old versions is moved into the private data collection of the event.
+// it returns false if every record is already current.
+func MigrateEvent(stubInterface shim.ChaincodeStubInterface, UUID string) (bool, error) {
+	event, err := LoadEventByUUID(stubInterface, UUID)
+	if err != nil {
+		return false, err
+	}
+	if event.UUID == "" {
+		return false, ErrEventNotFound.WithDetail(UUID)
+	}
+
+	migrated, err := isOutdated(stubInterface, event.GetKey(), DOC_TYPE_EVENT)
+	if err != nil {
+		return false, err
+	}
+
+	// old versions drew in the participant key order, the ordinals keep that order
+	indexing := !event.OrdinalIndexed
+	event.OrdinalIndexed = true
+	moved := make(map[string]Participant)
+	for idx := range event.Participants {
+		participant := &event.Participants[idx]
+		key, err := MakeParticipantKey(stubInterface, event.UUID, participant.UUID)
+		if err != nil {
+			return false, err
+		}
+		outdated, err := isOutdated(stubInterface, key, DOC_TYPE_PARTICIPANT)
+		if err != nil {
+			return false, err
+		}
+
+		// old versions did not index the events of participants
+		if outdated {
+			err = saveParticipantIndex(stubInterface, participant.UUID, event.UUID)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if indexing {
+			participant.Ordinal = int64(idx)
+			err = saveOrdinalIndex(stubInterface, event.UUID, *participant)
+			if err != nil {
+				return false, err
+			}
+			outdated = true
+		}
+
+		// the PII is the participant's own, the transient map of the migration does not belong to any participant
+		if participant.Information != "" || participant.AuthInformation != "" {
+			privateData, err := participant.commitPrivateData(stubInterface, &ParticipantPrivateData{
+				Information:     participant.Information,
+				AuthInformation: participant.AuthInformation,
+			})
+			if err != nil {
+				return false, err
+			}
+			err = privateData.SaveToLedger(stubInterface, event.GetPrivateCollection(), key)
+			if err != nil {
+				return false, err
+			}
+			moved[participant.UUID] = *participant
+			outdated = true
+		}
+		migrated = migrated || outdated
+	}
+	migrated = migrated || indexing
+	replacePrizeParticipants(event.Prizes, moved)
+
+	rounds, err := LoadRounds(stubInterface, event.UUID)
+	if err != nil {
+		return false, err
+	}
+	for idx := range rounds {
+		key, err := MakeRoundKey(stubInterface, event.UUID, rounds[idx].Index)
+		if err != nil {
+			return false, err
+		}
+		outdated, err := isOutdated(stubInterface, key, DOC_TYPE_ROUND)
+		if err != nil {
+			return false, err
+		}
+		if outdated || len(moved) > 0 {
+			replacePrizeParticipants(rounds[idx].Prizes, moved)
+			err = rounds[idx].SaveToLedger(stubInterface)
+			if err != nil {
+				return false, err
+			}
+			migrated = true
+		}
+	}
+
+	if !migrated {
+		return false, nil
+	}
+	// writes the event, the changed participants and the winners index
+	return true, event.SaveToLedger(stubInterface)
+}
+
+// replacePrizeParticipants replaces the copies of the participants in the prize results.
+func replacePrizeParticipants(prizes []Prize, participants map[string]Participant) {
+	for i := range prizes {
+		prize := &prizes[i]
+		for idx, winner := range prize.Winners {
+			if participant, exist := participants[winner.UUID]; exist {
+				prize.Winners[idx] = participant
+			}
+		}
+		for idx, alternate := range prize.Alternates {
+			if participant, exist := participants[alternate.UUID]; exist {
+				prize.Alternates[idx] = participant
+			}
+		}
+		for idx, disqualification := range prize.Disqualifications {
+			if participant, exist := participants[disqualification.Participant.UUID]; exist {
+				prize.Disqualifications[idx].Participant = participant
+			}
+		}
+	}
+}
+
+func isOutdated(stubInterface shim.ChaincodeStubInterface, key string, docType DocType) (bool, error) {
+	b, err := stubInterface.GetState(key)
+	if err != nil {
+		return false, err
+	}
+	version, err := RecordSchemaVersion(b)
+	if err != nil {
+		return false, err
+	}
+	return version < CurrentSchemaVersion(docType), nil
+}
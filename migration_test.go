@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// putLegacyEvent writes the version 0 drawn event and its participant as the first chaincode did.
+func putLegacyEvent(t *testing.T, m *identityStub) (string, string) {
+	event := &Event{}
+	participant := &Participant{}
+	unmarshalFixture(t, "event_v0_drawn.json", event)
+	unmarshalFixture(t, "participant_v0.json", participant)
+
+	m.MockTransactionStart("legacyTx")
+	m.PutState(event.GetKey(), readFixture(t, "event_v0_drawn.json"))
+	key, _ := MakeParticipantKey(m, event.UUID, participant.UUID)
+	m.PutState(key, readFixture(t, "participant_v0.json"))
+	m.MockTransactionEnd("legacyTx")
+	return event.UUID, participant.UUID
+}
+
+func unmarshalFixture(t *testing.T, name string, v interface{}) {
+	if err := json.Unmarshal(readFixture(t, name), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateEvents(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	eventUUID, participantUUID := putLegacyEvent(t, m)
+
+	m.setClient(t, "provider")
+	_, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 60,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+
+	// only administrators migrate
+	if _, ok = m.call(t, "migrateTx", "migrateEvents", MigrateEventsRequest{PageSize: 1}); ok {
+		t.Fatal("client without the admin attribute migrated")
+	}
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+
+	migrated := make([]string, 0)
+	request := MigrateEventsRequest{PageSize: 1}
+	for batch := 0; ; batch++ {
+		// PII in the transient map of the migration is not taken for the participants
+		m.transient = map[string][]byte{TRANSIENT_PARTICIPANT_KEY: []byte(`{"information":"mallory@example.com"}`)}
+		payload, ok := m.call(t, "migrateTx"+strconv.Itoa(batch), "migrateEvents", request)
+		if !ok {
+			t.FailNow()
+		}
+		result := &MigrationResult{}
+		unmarshalData(payload, result)
+		if result.Scanned != 1 {
+			t.Fatal("batch size is not kept")
+		}
+		migrated = append(migrated, result.Migrated...)
+		if result.Bookmark == "" {
+			break
+		}
+		request.Bookmark = result.Bookmark
+	}
+	if len(migrated) != 1 || migrated[0] != eventUUID {
+		t.Fatal("only the legacy event must be migrated")
+	}
+
+	// the records are rewritten in the current version, and the PII is moved into the private data
+	version, _ := RecordSchemaVersion(m.State[MakeKeyByUUID(eventUUID)])
+	if version != CurrentSchemaVersion(DOC_TYPE_EVENT) {
+		t.Fatal("event is not rewritten")
+	}
+	participantKey, _ := MakeParticipantKey(m, eventUUID, participantUUID)
+	participant := &Participant{}
+	if err := UnmarshalRecord(DOC_TYPE_PARTICIPANT, m.State[participantKey], participant); err != nil {
+		t.Fatal(err)
+	}
+	if participant.Information != "" || participant.Commitment == "" || participant.SchemaVersion != CurrentSchemaVersion(DOC_TYPE_PARTICIPANT) {
+		t.Fatal("participant PII is left in the world state")
+	}
+	privateData := &ParticipantPrivateData{}
+	if err := UnmarshalRecord(DOC_TYPE_PARTICIPANT_PRIVATE, m.PvtState[DEFAULT_PII_COLLECTION][participantKey], privateData); err != nil {
+		t.Fatal(err)
+	}
+	if commitment, _ := privateData.MakeCommitment(); privateData.Information != "alice@example.com" || commitment != participant.Commitment {
+		t.Fatal("private data is not matched with the commitment")
+	}
+	indexKey, _ := m.CreateCompositeKey("participantEvents", []string{participantUUID, eventUUID})
+	if m.State[indexKey] == nil {
+		t.Fatal("legacy participant is not indexed")
+	}
+	ordinalKey, _ := MakeOrdinalKey(m, eventUUID, 0)
+	if string(m.State[ordinalKey]) != participantUUID || participant.Ordinal != 0 {
+		t.Fatal("legacy participant has no ordinal")
+	}
+
+	event := &Event{}
+	if err := UnmarshalRecord(DOC_TYPE_EVENT, m.State[MakeKeyByUUID(eventUUID)], event); err != nil {
+		t.Fatal(err)
+	}
+	winner := event.Prizes[0].Winners[0]
+	if winner.Information != "" || winner.Commitment != participant.Commitment || len(event.Prizes[0].Claims) != 1 {
+		t.Fatal("prize results are not migrated")
+	}
+
+	// migrated events are not rewritten again
+	payload, ok := m.call(t, "migrateTx", "migrateEvents", MigrateEventsRequest{PageSize: 10})
+	if !ok {
+		t.FailNow()
+	}
+	result := &MigrationResult{}
+	unmarshalData(payload, result)
+	if result.Scanned != 2 || len(result.Migrated) != 0 || result.Bookmark != "" {
+		t.Fatal("current events are migrated")
+	}
+}
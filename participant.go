@@ -1,14 +1,171 @@
 package main
 
-import "encoding/json"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// DEFAULT_PII_COLLECTION is the private data collection of participant PII when the event does not configure one.
+const DEFAULT_PII_COLLECTION = "collectionParticipantPII"
+
+// TRANSIENT_PARTICIPANT_KEY is the transient map key of ParticipantPrivateData in participation proposals.
+const TRANSIENT_PARTICIPANT_KEY = "participant"
 
 type Participant struct {
+	DocType         DocType     `json:"docType" metadata:",optional"`
+	SchemaVersion   int64       `json:"schemaVersion" metadata:",optional"`
 	UUID            string      `json:"UUID"`
-	Information     string      `json:"information"`
-	AuthInformation string      `json:"authInformation"`
-	ParticipateTx   Transaction `json:"participateTx"`
+	Information     string      `json:"information" metadata:",optional"`     // empty in the world state, kept in the private data collection
+	AuthInformation string      `json:"authInformation" metadata:",optional"` // empty in the world state, kept in the private data collection
+	Commitment      string      `json:"commitment" metadata:",optional"`      // salted hash of the private data
+	Erased          bool        `json:"erased" metadata:",optional"`          // PII is erased, UUID and commitment are kept for verification
+	Ordinal         int64       `json:"ordinal" metadata:",optional"`         // position in the draw order, -1 if the event is not indexed by ordinal or withdrawn
+	Withdrawn       bool        `json:"withdrawn" metadata:",optional"`       // left the event before the deadline, the record is kept for the history
+	ParticipateTx   Transaction `json:"participateTx" metadata:",optional"`
+	WithdrawTx      Transaction `json:"withdrawTx" metadata:",optional"`
+}
+
+// ParticipantPrivateData is participant PII recorded in the private data collection of the event,
+// under the same composite key as the public participant record.
+type ParticipantPrivateData struct {
+	DocType         DocType `json:"docType"`
+	SchemaVersion   int64   `json:"schemaVersion,omitempty"` // omitted in the commitment, so commitments do not change with the version
+	Information     string  `json:"information"`
+	AuthInformation string  `json:"authInformation"`
+	Salt            string  `json:"salt"`
 }
 
 func (p Participant) ToLedgerBinary() ([]byte, error) {
+	p.DocType = DOC_TYPE_PARTICIPANT
+	p.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_PARTICIPANT)
 	return json.Marshal(p)
 }
+
+// MakeCommitment returns hex encoded sha256 hash of the private data.
+func (d ParticipantPrivateData) MakeCommitment() (string, error) {
+	d.DocType = ""
+	d.SchemaVersion = 0
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// SeparatePrivateData takes the PII of the participant from the transient map into private data and leaves the commitment.
+// PII in args is rejected, since args are recorded in the block.
+func (p *Participant) SeparatePrivateData(stubInterface shim.ChaincodeStubInterface) (*ParticipantPrivateData, error) {
+	if err := p.checkNoPrivateData("", TRANSIENT_PARTICIPANT_KEY); err != nil {
+		return nil, err
+	}
+
+	privateData := &ParticipantPrivateData{}
+	transient, err := stubInterface.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	if b, exist := transient[TRANSIENT_PARTICIPANT_KEY]; exist {
+		err = json.Unmarshal(b, privateData)
+		if err != nil {
+			return nil, err
+		}
+
+		v := new(Validation)
+		privateData.Validate(v, 0)
+		if err := v.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return p.commitPrivateData(stubInterface, privateData)
+}
+
+// checkNoPrivateData rejects the PII of the participant in args, which is sent in the transient key instead.
+// field prefixes the rejected field.
+func (p *Participant) checkNoPrivateData(field string, transientKey string) error {
+	if p.Information != "" {
+		return ErrInvalidArg.WithField(field + "information").WithDetail("PII is sent in the " + transientKey + " transient key")
+	}
+	if p.AuthInformation != "" {
+		return ErrInvalidArg.WithField(field + "authInformation").WithDetail("PII is sent in the " + transientKey + " transient key")
+	}
+	return nil
+}
+
+// commitPrivateData leaves the commitment of the private data in the participant, without the PII.
+func (p *Participant) commitPrivateData(stubInterface shim.ChaincodeStubInterface, privateData *ParticipantPrivateData) (*ParticipantPrivateData, error) {
+	if privateData.Salt == "" {
+		salt, err := makeSalt(stubInterface, p.UUID, privateData)
+		if err != nil {
+			return nil, err
+		}
+		privateData.Salt = salt
+	}
+
+	var err error
+	p.Commitment, err = privateData.MakeCommitment()
+	if err != nil {
+		return nil, err
+	}
+	p.Information = ""
+	p.AuthInformation = ""
+	privateData.DocType = DOC_TYPE_PARTICIPANT_PRIVATE
+	privateData.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_PARTICIPANT_PRIVATE)
+	return privateData, nil
+}
+
+// makeSalt returns the salt of private data sent without one. it is derived from the signature of the proposal,
+// which every endorser receives but the block does not record, so a commitment of guessable PII cannot be brute-forced from the ledger.
+// private data without PII has nothing to hide, and is salted with the transaction ID.
+func makeSalt(stubInterface shim.ChaincodeStubInterface, participantUUID string, privateData *ParticipantPrivateData) (string, error) {
+	seed := stubInterface.GetTxID()
+	if privateData.Information != "" || privateData.AuthInformation != "" {
+		signedProposal, err := stubInterface.GetSignedProposal()
+		if err != nil || signedProposal == nil || len(signedProposal.Signature) == 0 {
+			return "", ErrRequired(TRANSIENT_PARTICIPANT_KEY + ".salt")
+		}
+		seed = hex.EncodeToString(signedProposal.Signature)
+	}
+	hash := sha256.Sum256([]byte(seed + "_" + participantUUID))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// MakeParticipantKey returns composite key of the participant ( event_UUID_{eventUUID}~participants~{participantUUID} )
+func MakeParticipantKey(stubInterface shim.ChaincodeStubInterface, eventUUID string, participantUUID string) (string, error) {
+	return stubInterface.CreateCompositeKey(MakeKeyByUUID(eventUUID), []string{"participants", participantUUID})
+}
+
+func (d *ParticipantPrivateData) SaveToLedger(stubInterface shim.ChaincodeStubInterface, collection string, key string) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutPrivateData(collection, key, b)
+}
+
+// LoadParticipantPrivateData loads the private data of the participant.
+// it returns nil if the peer does not have the private data.
+func LoadParticipantPrivateData(stubInterface shim.ChaincodeStubInterface, event *Event, participantUUID string) (*ParticipantPrivateData, error) {
+	key, err := MakeParticipantKey(stubInterface, event.UUID, participantUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := stubInterface.GetPrivateData(event.GetPrivateCollection(), key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+
+	privateData := &ParticipantPrivateData{}
+	err = UnmarshalRecord(DOC_TYPE_PARTICIPANT_PRIVATE, b, privateData)
+	if err != nil {
+		return nil, err
+	}
+	return privateData, nil
+}
@@ -1,28 +1,17 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/binary"
-	"strconv"
+	"github.com/sslab-archive/block_lottery_cc/draw"
 )
 
-func FisherYatesShuffle(arr []Participant ,randomSource string) []Participant {
+// FisherYatesShuffle returns the participants in the shuffled order of the draw package.
+func FisherYatesShuffle(arr []Participant, randomSource string) []Participant {
 
 	shuffledData := make([]Participant, len(arr))
 
-	for i := 0; i < len(arr); i++ {
-		shuffledData[i] = arr[i]
-	}
-
-	for j := len(arr) - 1; j > 0; j-- {
-		hash := sha256.Sum256([]byte(randomSource+strconv.Itoa(j)))
-		var h []byte
-		h = hash[:]
-		seed := binary.BigEndian.Uint64(h)
-		k := seed % uint64(len(arr))
-		shuffledData[j], shuffledData[k] = shuffledData[k], shuffledData[j]
+	for i, idx := range draw.ShuffleIndices(len(arr), randomSource) {
+		shuffledData[i] = arr[idx]
 	}
 
 	return shuffledData
 }
-
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func TestParticipatePrivateData(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	// PII in args would be recorded in the block
+	m.setClient(t, "participant1")
+	request := ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "participant1", Information: "alice@example.com"}}
+	if _, ok = m.call(t, "participateTx", "participateLotteryEvent", request); ok {
+		t.Fatal("PII in args is accepted")
+	}
+
+	request.Participant.Information = ""
+	m.setPrivateData("alice@example.com", "auth")
+	if _, ok = m.call(t, "participateTx", "participateLotteryEvent", request); !ok {
+		t.FailNow()
+	}
+
+	key, _ := m.CreateCompositeKey(event.GetKey(), []string{"participants", "participant1"})
+	participant := &Participant{}
+	if err := json.Unmarshal(m.State[key], participant); err != nil {
+		t.Fatal(err)
+	}
+	if participant.Information != "" || participant.AuthInformation != "" {
+		t.Error("participant PII must not be in the world state")
+	}
+
+	privateData := &ParticipantPrivateData{}
+	if err := json.Unmarshal(m.PvtState[DEFAULT_PII_COLLECTION][key], privateData); err != nil {
+		t.Fatal(err)
+	}
+	if privateData.Information != "alice@example.com" || privateData.AuthInformation != "auth" {
+		t.Errorf("unexpected private data : %+v", privateData)
+	}
+	commitment, _ := privateData.MakeCommitment()
+	if commitment != participant.Commitment {
+		t.Errorf("commitment %s is not matched with private data %s", participant.Commitment, commitment)
+	}
+	txSalt := sha256.Sum256([]byte("participateTx_participant1"))
+	if privateData.Salt == hex.EncodeToString(txSalt[:]) {
+		t.Error("salt is derived from the block")
+	}
+
+	if _, ok = m.call(t, "queryTx", "queryParticipantPrivateData", QueryParticipantPrivateDataRequest{EventUUID: event.UUID, ParticipantUUID: "participant1"}); !ok {
+		t.FailNow()
+	}
+}
+
+func TestCommitPrivateDataSalt(t *testing.T) {
+	// the mock stub has no signed proposal to derive a salt from
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+	m.MockTransactionStart("participateTx")
+	defer m.MockTransactionEnd("participateTx")
+
+	participant := &Participant{UUID: "participant1"}
+	if _, err := participant.commitPrivateData(m, &ParticipantPrivateData{Information: "alice@example.com"}); err == nil {
+		t.Fatal("PII is committed with a salt from the block")
+	}
+	privateData, err := participant.commitPrivateData(m, &ParticipantPrivateData{Information: "alice@example.com", Salt: "salt"})
+	if err != nil || privateData.Salt != "salt" {
+		t.Fatal("salt of the client is not kept")
+	}
+	if _, err = participant.commitPrivateData(m, &ParticipantPrivateData{}); err != nil {
+		t.Fatal("private data without PII is not committed")
+	}
+}
+
+func TestWithdrawParticipation(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 3}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	uuids := []string{"participant1", "participant2", "participant3", "participant4"}
+	for _, uuid := range uuids {
+		m.setClient(t, uuid)
+		m.setPrivateData(uuid+"@example.com", "")
+		_, ok = m.call(t, "participateTx"+uuid, "participateLotteryEvent", ParticipateLotteryRequest{
+			EventUUID:   event.UUID,
+			Participant: Participant{UUID: uuid},
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+
+	request := WithdrawParticipationRequest{EventUUID: event.UUID, ParticipantUUID: "participant2"}
+	m.setClient(t, "provider")
+	if _, ok = m.call(t, "withdrawTx", "withdrawParticipation", request); ok {
+		t.Fatal("event creator withdrew the participant")
+	}
+	m.setClient(t, "participant2")
+	if _, ok = m.call(t, "withdrawTx", "withdrawParticipation", request); !ok {
+		t.FailNow()
+	}
+
+	// the record is kept, and the last participant takes the freed ordinal
+	participantKey, _ := MakeParticipantKey(m, event.UUID, "participant2")
+	withdrawn := &Participant{}
+	if err := UnmarshalRecord(DOC_TYPE_PARTICIPANT, m.State[participantKey], withdrawn); err != nil {
+		t.Fatal(err)
+	}
+	if !withdrawn.Withdrawn || withdrawn.WithdrawTx.ID != "withdrawTx" {
+		t.Fatal("participant record is not marked withdrawn")
+	}
+	for ordinal, expected := range []string{"participant1", "participant4", "participant3", ""} {
+		ordinalKey, _ := MakeOrdinalKey(m, event.UUID, int64(ordinal))
+		if string(m.State[ordinalKey]) != expected {
+			t.Fatal("ordinal " + strconv.Itoa(ordinal) + " is " + string(m.State[ordinalKey]))
+		}
+	}
+
+	m.setClient(t, "provider")
+	time.Sleep(2 * time.Second)
+	if _, ok = m.call(t, "drawTx", "drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "providerHash"}); !ok {
+		t.FailNow()
+	}
+
+	drawn, _ := LoadEventByUUID(m, event.UUID)
+	if drawn.ParticipantNum != 3 || len(drawn.Participants) != 4 {
+		t.Fatal("withdrawn participant is counted")
+	}
+	for _, winner := range drawn.Prizes[0].Winners {
+		if winner.UUID == "participant2" {
+			t.Fatal("withdrawn participant is drawn")
+		}
+	}
+	if result := drawn.Verify(""); !result.Verified {
+		t.Fatal("draw is not verified : " + result.Mismatch)
+	}
+	certificate, err := drawn.MakeDrawCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certificate.Participants) != 3 {
+		t.Fatal("withdrawn participant is in the certificate")
+	}
+
+	m.setClient(t, "participant1")
+	if _, ok = m.call(t, "withdrawTx2", "withdrawParticipation", WithdrawParticipationRequest{EventUUID: event.UUID, ParticipantUUID: "participant1"}); ok {
+		t.Fatal("participant withdrew after the deadline")
+	}
+}
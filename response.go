@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// SCHEMA_VERSION is the version of the response envelope and the returned objects.
+const SCHEMA_VERSION = "1.0"
+
+// SuccessEnvelope wraps the payload of every successful response.
+type SuccessEnvelope struct {
+	SchemaVersion string      `json:"schemaVersion"`
+	Data          interface{} `json:"data"`
+}
+
+// ErrorEnvelope is the message of every error response.
+type ErrorEnvelope struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	Error         *LotteryError `json:"error"`
+}
+
+// SuccessResponse returns the data in the success envelope.
+func SuccessResponse(data interface{}) pb.Response {
+	b, err := json.Marshal(SuccessEnvelope{SchemaVersion: SCHEMA_VERSION, Data: data})
+	if err != nil {
+		return ErrorResponse(err)
+	}
+	return shim.Success(b)
+}
+
+// ErrorResponse returns the error in the error envelope.
+// errors out of the catalog are reported as ErrInternal.
+func ErrorResponse(err error) pb.Response {
+	b, marshalErr := json.Marshal(ErrorEnvelope{SchemaVersion: SCHEMA_VERSION, Error: ToLotteryError(err)})
+	if marshalErr != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Error(string(b))
+}
+
+// ParseErrorResponse reads the error envelope of the response message.
+func ParseErrorResponse(message string) (*LotteryError, error) {
+	envelope := &ErrorEnvelope{}
+	err := json.Unmarshal([]byte(message), envelope)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Error, nil
+}
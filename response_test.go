@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// unmarshalData reads the data of the success envelope.
+func unmarshalData(payload []byte, v interface{}) error {
+	envelope := &SuccessEnvelope{Data: v}
+	return json.Unmarshal(payload, envelope)
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+
+	createRequest, _ := json.Marshal(CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	res := m.MockInvoke("createTx", [][]byte{[]byte("invoke"), []byte("createLotteryEvent"), createRequest})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	envelope := &SuccessEnvelope{Data: &Event{}}
+	if err := json.Unmarshal(res.Payload, envelope); err != nil {
+		t.Fatal(err)
+	}
+	event := envelope.Data.(*Event)
+	if envelope.SchemaVersion != SCHEMA_VERSION || event.UUID == "" {
+		t.Errorf("unexpected envelope : %s", res.Payload)
+	}
+
+	time.Sleep(2 * time.Second)
+	participateRequest, _ := json.Marshal(ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "participant1"}})
+	res = m.MockInvoke("participateTx", [][]byte{[]byte("invoke"), []byte("participateLotteryEvent"), participateRequest})
+	lotteryErr, err := ParseErrorResponse(res.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ErrDeadlinePassed.Is(lotteryErr) || lotteryErr.Name != "DEADLINE_PASSED" {
+		t.Errorf("expected %s, got %s", ErrDeadlinePassed, res.Message)
+	}
+
+	res = m.MockInvoke("createTx", [][]byte{[]byte("invoke"), []byte("createLotteryEvent"), []byte(`{"eventName":"event"}`)})
+	lotteryErr, err = ParseErrorResponse(res.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ErrInvalidRequest.Is(lotteryErr) || lotteryErr.Field == "" || len(lotteryErr.Violations) == 0 {
+		t.Errorf("expected %s with field, got %s", ErrInvalidRequest, res.Message)
+	}
+}
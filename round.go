@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Round is a staged draw over the participant pool of an event.
+// rounds are recorded in composite key ( event_UUID_{eventUUID}~rounds~{index} )
+type Round struct {
+	DocType                DocType `json:"docType"`
+	SchemaVersion          int64   `json:"schemaVersion"`
+	EventUUID              string  `json:"eventUUID"`
+	ProviderID             string  `json:"providerID"` // client ID of the event creator
+	Index                  int64   `json:"index"`
+	Status                 Status  `json:"status"`
+	DrawTime               int64   `json:"drawTime"` // UNIX timestamp, participants joined until then take part in the round
+	ExcludePreviousWinners bool    `json:"excludePreviousWinners"`
+	Prizes                 []Prize `json:"prizes"`
+
+	// seed inputs
+	TargetBlock         BlockInfo `json:"targetBlock"`
+	ServiceProviderHash string    `json:"serviceProviderHash"`
+
+	// result data
+	SeedHash             string   `json:"seedHash"`
+	ExcludedParticipants []string `json:"excludedParticipants"` // winners of previous rounds left out of the pool
+
+	// transaction info
+	RoundCreateTx Transaction `json:"roundCreateTx"`
+	DrawTx        Transaction `json:"drawTx"`
+}
+
+func NewRound(event *Event, request *AddLotteryRoundRequest, createRoundTx Transaction) Round {
+	return Round{
+		DocType:                DOC_TYPE_ROUND,
+		SchemaVersion:          CurrentSchemaVersion(DOC_TYPE_ROUND),
+		EventUUID:              event.UUID,
+		ProviderID:             event.EventCreateTx.ClientID,
+		Index:                  event.RoundNum,
+		Status:                 STATUS_REGISTERD,
+		DrawTime:               request.DrawTime,
+		ExcludePreviousWinners: request.ExcludePreviousWinners,
+		Prizes:                 newPrizes(request.Prizes, createRoundTx),
+		TargetBlock:            request.TargetBlock,
+		ServiceProviderHash:    request.ServiceProviderHash,
+		SeedHash:               "",
+		ExcludedParticipants:   make([]string, 0),
+		RoundCreateTx:          createRoundTx,
+		DrawTx:                 Transaction{},
+	}
+}
+
+func MakeRoundKey(stubInterface shim.ChaincodeStubInterface, eventUUID string, index int64) (string, error) {
+	return stubInterface.CreateCompositeKey(MakeKeyByUUID(eventUUID), []string{"rounds", fmt.Sprintf("%08d", index)})
+}
+
+func (r *Round) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
+	key, err := MakeRoundKey(stubInterface, r.EventUUID, r.Index)
+	if err != nil {
+		return err
+	}
+
+	r.DocType = DOC_TYPE_ROUND
+	r.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_ROUND)
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	err = stubInterface.PutState(key, b)
+	if err != nil {
+		return err
+	}
+
+	if r.Status == STATUS_DRAWN {
+		return saveWinnerIndex(stubInterface, r.EventUUID, r.Index, r.ProviderID, r.Prizes, r.DrawTx.Timestamp)
+	}
+	return nil
+}
+
+// Draw draws the round over the event participants joined until DrawTime.
+// previousRounds must contain every round of the event ordered by index.
+func (r *Round) Draw(event *Event, previousRounds []Round, tx Transaction) error {
+	if r.Status != STATUS_REGISTERD {
+		return ErrInvalidStatus.WithDetail("round status is not registered. check is already drawn")
+	}
+	if event.Status == STATUS_REMOVED {
+		return ErrInvalidStatus.WithDetail("event is removed")
+	}
+	if tx.Timestamp < r.DrawTime {
+		return ErrDeadlineNotPassed.WithDetail("draw time of the round is not passed")
+	}
+
+	r.ExcludedParticipants = make([]string, 0)
+	if r.ExcludePreviousWinners {
+		for _, round := range previousRounds {
+			if round.Index >= r.Index || round.Status != STATUS_DRAWN {
+				continue
+			}
+			for _, prize := range round.Prizes {
+				for _, winner := range prize.Winners {
+					r.ExcludedParticipants = append(r.ExcludedParticipants, winner.UUID)
+				}
+			}
+		}
+	}
+
+	r.Status = STATUS_DRAWN
+	r.SeedHash = MakeSeed(r.TargetBlock.Hash, r.ServiceProviderHash)
+	r.DrawTx = tx
+
+	drawPrizes(r.Prizes, r.drawingParticipants(event), r.SeedHash, tx)
+	return nil
+}
+
+// drawingParticipants returns event participants joined until DrawTime, without the excluded participants.
+func (r *Round) drawingParticipants(event *Event) []Participant {
+	excluded := make(map[string]bool)
+	for _, participantUUID := range r.ExcludedParticipants {
+		excluded[participantUUID] = true
+	}
+
+	participants := make([]Participant, 0)
+	for _, participant := range event.drawingParticipants() {
+		if participant.ParticipateTx.Timestamp > r.DrawTime || excluded[participant.UUID] {
+			continue
+		}
+		participants = append(participants, participant)
+	}
+	return participants
+}
+
+// Verify re-draws the round from the recorded seed inputs and compares the results.
+func (r *Round) Verify(event *Event, inputHash string) VerifyResult {
+	result := VerifyResult{
+		EventUUID:  r.EventUUID,
+		RoundIndex: r.Index,
+		SeedHash:   r.SeedHash,
+	}
+	if r.Status != STATUS_DRAWN {
+		result.Mismatch = "round is not drawn"
+		return result
+	}
+
+	result.Mismatch = verifySeed(r.SeedHash, MakeSeed(r.TargetBlock.Hash, r.ServiceProviderHash), inputHash)
+	if result.Mismatch == "" {
+		result.Mismatch = verifyPrizes(r.Prizes, r.drawingParticipants(event), r.SeedHash)
+	}
+	result.Verified = result.Mismatch == ""
+	return result
+}
+
+func LoadRound(stubInterface shim.ChaincodeStubInterface, eventUUID string, index int64) (*Round, error) {
+	key, err := MakeRoundKey(stubInterface, eventUUID, index)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := stubInterface.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return &Round{}, nil
+	}
+
+	round := &Round{}
+	err = UnmarshalRecord(DOC_TYPE_ROUND, b, round)
+	if err != nil {
+		return nil, err
+	}
+	return round, nil
+}
+
+func LoadRounds(stubInterface shim.ChaincodeStubInterface, eventUUID string) ([]Round, error) {
+	roundIterator, err := stubInterface.GetStateByPartialCompositeKey(MakeKeyByUUID(eventUUID), []string{"rounds"})
+	if err != nil {
+		return nil, err
+	}
+	defer roundIterator.Close()
+
+	rounds := make([]Round, 0)
+	for roundIterator.HasNext() {
+		kv, err := roundIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		round := &Round{}
+		err = UnmarshalRecord(DOC_TYPE_ROUND, kv.Value, round)
+		if err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, *round)
+	}
+	return rounds, nil
+}
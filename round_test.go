@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundDrawExcludePreviousWinners(t *testing.T) {
+	event := newDrawTestEvent(10)
+	for idx := range event.Participants {
+		event.Participants[idx].ParticipateTx.Timestamp = 50
+	}
+
+	rounds := []Round{
+		{EventUUID: event.UUID, Index: 0, Status: STATUS_REGISTERD, DrawTime: 100, Prizes: []Prize{{UUID: "weekly1", WinnerNum: 3}}},
+		{EventUUID: event.UUID, Index: 1, Status: STATUS_REGISTERD, DrawTime: 200, Prizes: []Prize{{UUID: "weekly2", WinnerNum: 7}}, ExcludePreviousWinners: true},
+	}
+	rounds[0].TargetBlock.Hash = "firstBlock"
+	rounds[1].TargetBlock.Hash = "secondBlock"
+
+	// participant joined after the draw time is not in the first round
+	event.Participants[9].ParticipateTx.Timestamp = 150
+
+	err := rounds[0].Draw(event, rounds, Transaction{ID: "round0", Timestamp: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, winner := range rounds[0].Prizes[0].Winners {
+		if winner.UUID == event.Participants[9].UUID {
+			t.Error("late participant must not take part in the first round")
+		}
+	}
+
+	err = rounds[1].Draw(event, rounds, Transaction{ID: "round1", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rounds[1].ExcludedParticipants) != 3 {
+		t.Fatalf("expected 3 excluded participants, got %d", len(rounds[1].ExcludedParticipants))
+	}
+	firstWinners := make(map[string]bool)
+	for _, winner := range rounds[0].Prizes[0].Winners {
+		firstWinners[winner.UUID] = true
+	}
+	for _, winner := range rounds[1].Prizes[0].Winners {
+		if firstWinners[winner.UUID] {
+			t.Errorf("previous winner %s won again", winner.UUID)
+		}
+	}
+
+	for _, round := range rounds {
+		result := round.Verify(event, "")
+		if !result.Verified {
+			t.Errorf("round %d is not verified : %s", round.Index, result.Mismatch)
+		}
+	}
+
+	rounds[1].Prizes[0].Winners[0], rounds[1].Prizes[0].Winners[1] = rounds[1].Prizes[0].Winners[1], rounds[1].Prizes[0].Winners[0]
+	if rounds[1].Verify(event, "").Verified {
+		t.Error("tampered round must not be verified")
+	}
+}
+
+func TestEventVerifyReplaysDisqualifications(t *testing.T) {
+	event := newDrawTestEvent(10)
+	err := event.Draw(Transaction{ID: "drawTx", Timestamp: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = event.DisqualifyWinner("prize2", event.Prizes[1].Winners[1].UUID, "ineligible", Transaction{ID: "disqualifyTx", Timestamp: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := event.Verify(event.SeedHash)
+	if !result.Verified {
+		t.Fatal(result.Mismatch)
+	}
+
+	result = event.Verify("otherSeed")
+	if result.Verified {
+		t.Error("verify must fail with other input hash")
+	}
+}
+
+func TestRoundAuthorization(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	event := saveDrawnEvent(t, m)
+	participant := event.Participants[0].UUID
+
+	addRequest := AddLotteryRoundRequest{
+		EventUUID:   event.UUID,
+		DrawTime:    time.Now().Unix() + 1,
+		Prizes:      []Prize{{Title: "weekly", WinnerNum: 1}},
+		TargetBlock: BlockInfo{BlockType: BITCOIN, Height: 2},
+	}
+	m.setClient(t, participant)
+	if _, ok := m.call(t, "roundTx", "addLotteryRound", addRequest); ok {
+		t.Fatal("participant added a round")
+	}
+	m.setClient(t, "provider")
+	if _, ok := m.call(t, "roundTx", "addLotteryRound", addRequest); !ok {
+		t.FailNow()
+	}
+
+	time.Sleep(2 * time.Second)
+	drawRequest := DrawLotteryRoundRequest{EventUUID: event.UUID, TargetBlock: BlockInfo{BlockType: BITCOIN, Height: 2, Hash: "roundBlock"}}
+	m.setClient(t, participant)
+	if _, ok := m.call(t, "roundDrawTx", "drawLotteryRound", drawRequest); ok {
+		t.Fatal("participant drew a round")
+	}
+	m.setClient(t, "provider")
+	if _, ok := m.call(t, "roundDrawTx", "drawLotteryRound", drawRequest); !ok {
+		t.Fatal("event creator is rejected")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// LEGACY_INVOKE_FUNCTION is the function name of the legacy form, which carries the operation name in args[0].
+const LEGACY_INVOKE_FUNCTION = "invoke"
+
+// Operation is an entry of the router registry.
+type Operation struct {
+	Name    string
+	ArgsNum int // number of args following the operation name
+	Handler func(l *LotteryChaincode, stubInterface shim.ChaincodeStubInterface, args []string) pb.Response
+}
+
+// Router dispatches both invoke forms to the registered operations.
+//   - direct : function = operation name, args = [json args]
+//   - legacy : function = "invoke", args = [operation name, json args]
+//
+// handlers always receive args[0] = operation name, args[1:] = operation args.
+type Router struct {
+	operations map[string]Operation
+}
+
+func NewRouter() *Router {
+	return &Router{operations: make(map[string]Operation)}
+}
+
+// Register adds the operation. registering the same name twice panics, because it is a programming error.
+func (r *Router) Register(operation Operation) {
+	if _, exist := r.operations[operation.Name]; exist || operation.Name == LEGACY_INVOKE_FUNCTION {
+		panic("operation is already registered : " + operation.Name)
+	}
+	r.operations[operation.Name] = operation
+}
+
+// Handles reports whether the function is routed by the router.
+func (r *Router) Handles(function string) bool {
+	_, exist := r.operations[function]
+	return exist || function == LEGACY_INVOKE_FUNCTION
+}
+
+// Lookup returns the operation and its args in the handler form.
+func (r *Router) Lookup(function string, args []string) (*Operation, []string, error) {
+	name := function
+	params := args
+	if function == LEGACY_INVOKE_FUNCTION {
+		if len(args) == 0 {
+			return nil, nil, ErrInvalidArgsNum.WithDetail("operation name is missing")
+		}
+		name = args[0]
+		params = args[1:]
+	}
+
+	operation, exist := r.operations[name]
+	if !exist {
+		return nil, nil, ErrUnknownFunction.WithDetail(name)
+	}
+	if len(params) != operation.ArgsNum {
+		return nil, nil, ErrInvalidArgsNum.WithDetail(name + " requires " + strconv.Itoa(operation.ArgsNum) + " args")
+	}
+	return &operation, append([]string{name}, params...), nil
+}
+
+// Route calls the operation of the invocation.
+func (r *Router) Route(l *LotteryChaincode, stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	operation, handlerArgs, err := r.Lookup(function, args)
+	if err != nil {
+		return ErrorResponse(err)
+	}
+
+	logger.Info("Invoked method is " + operation.Name)
+	return operation.Handler(l, stub, handlerArgs)
+}
+
+// Names returns the registered operation names in order.
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.operations))
+	for name := range r.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
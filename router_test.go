@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func TestRouterDirectAndLegacyForm(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+
+	createRequest, _ := json.Marshal(CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+
+		ServiceProviderHash: "providerHash",
+	})
+	res := m.MockInvoke("createTx", [][]byte{[]byte("createLotteryEvent"), createRequest})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	event := &Event{}
+	if err := unmarshalData(res.Payload, event); err != nil {
+		t.Fatal(err)
+	}
+
+	queryRequest, _ := json.Marshal(QueryLotteryByEventIDRequest{QueryLotteryRequest{QUERY_BY_EVENT_ID}, event.UUID})
+	res = m.MockInvoke("queryTx", [][]byte{[]byte("invoke"), []byte("queryLotteryEvent"), queryRequest})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	cases := map[string][][]byte{
+		"missing args":        {[]byte("queryLotteryEvent")},
+		"too many args":       {[]byte("queryLotteryEvent"), queryRequest, queryRequest},
+		"legacy missing args": {[]byte("invoke"), []byte("queryLotteryEvent")},
+		"legacy without name": {[]byte("invoke")},
+	}
+	for name, args := range cases {
+		res = m.MockInvoke("queryTx", args)
+		lotteryErr, err := ParseErrorResponse(res.Message)
+		if err != nil || !ErrInvalidArgsNum.Is(lotteryErr) {
+			t.Errorf("%s : expected %s, got %s", name, ErrInvalidArgsNum, res.Message)
+		}
+	}
+
+	res = m.MockInvoke("queryTx", [][]byte{[]byte("invoke"), []byte("unknownOperation"), queryRequest})
+	lotteryErr, err := ParseErrorResponse(res.Message)
+	if err != nil || !ErrUnknownFunction.Is(lotteryErr) {
+		t.Errorf("expected %s, got %s", ErrUnknownFunction, res.Message)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// RecordUpgrade changes a stored record of a schema version to the next version.
+// upgrades work on the raw JSON object, so they keep working after the Go types change.
+type RecordUpgrade func(record map[string]interface{}) error
+
+// recordUpgrades lists the upgrades of each doc type, upgrades[v] upgrades version v to v+1.
+// the current schema version of a doc type is the number of its upgrades.
+// records written before the version was recorded have no schemaVersion and are version 0.
+var recordUpgrades = map[DocType][]RecordUpgrade{
+	DOC_TYPE_CONFIG:              {},
+	DOC_TYPE_DRAW_STATE:          {},
+	DOC_TYPE_EVENT:               {upgradeEventV0, upgradeEventV1, upgradeEventV2, upgradeEventV3, upgradeEventV4, upgradeEventV5},
+	DOC_TYPE_ERASURE_RECEIPT:     {setDocType(DOC_TYPE_ERASURE_RECEIPT)},
+	DOC_TYPE_PARTICIPANT:         {setDocType(DOC_TYPE_PARTICIPANT), upgradeParticipantV1},
+	DOC_TYPE_PARTICIPANT_PRIVATE: {setDocType(DOC_TYPE_PARTICIPANT_PRIVATE)},
+	DOC_TYPE_ROUND:               {setDocType(DOC_TYPE_ROUND)},
+	DOC_TYPE_TEMPLATE:            {setDocType(DOC_TYPE_TEMPLATE)},
+	DOC_TYPE_TEMPLATE_VERSION:    {setDocType(DOC_TYPE_TEMPLATE_VERSION)},
+	DOC_TYPE_WIN_RECORD:          {setDocType(DOC_TYPE_WIN_RECORD)},
+}
+
+// CurrentSchemaVersion returns the schema version records of the doc type are written in.
+func CurrentSchemaVersion(docType DocType) int64 {
+	return int64(len(recordUpgrades[docType]))
+}
+
+// RecordSchemaVersion returns the schema version of the stored record.
+func RecordSchemaVersion(b []byte) (int64, error) {
+	record := struct {
+		SchemaVersion int64 `json:"schemaVersion"`
+	}{}
+	err := json.Unmarshal(b, &record)
+	if err != nil {
+		return 0, err
+	}
+	return record.SchemaVersion, nil
+}
+
+// UpgradeRecord returns the record upgraded to the current schema version of the doc type.
+// the record is returned as it is if it is already current.
+func UpgradeRecord(docType DocType, b []byte) ([]byte, error) {
+	version, err := RecordSchemaVersion(b)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrades := recordUpgrades[docType]
+	if version == int64(len(upgrades)) {
+		return b, nil
+	}
+	if version < 0 || version > int64(len(upgrades)) {
+		return nil, ErrUnsupportedSchemaVersion.WithDetail(string(docType) + " version " + strconv.FormatInt(version, 10))
+	}
+
+	// numbers are kept as they are written, int64 values would lose precision as float64
+	record := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err = decoder.Decode(&record)
+	if err != nil {
+		return nil, err
+	}
+
+	for ; version < int64(len(upgrades)); version++ {
+		err = upgrades[version](record)
+		if err != nil {
+			return nil, err
+		}
+	}
+	record["schemaVersion"] = version
+	return json.Marshal(record)
+}
+
+// UnmarshalRecord upgrades the stored record to the current schema version and unmarshals it into v.
+func UnmarshalRecord(docType DocType, b []byte, v interface{}) error {
+	upgraded, err := UpgradeRecord(docType, b)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+func setDocType(docType DocType) RecordUpgrade {
+	return func(record map[string]interface{}) error {
+		record["docType"] = string(docType)
+		return nil
+	}
+}
+
+// upgradeEventV0 upgrades events written before the schema version was recorded.
+// the first events had no docType, so rich queries did not find them, and null lists.
+// prizes drawn before claims were tracked get pending claims without a deadline.
+func upgradeEventV0(record map[string]interface{}) error {
+	record["docType"] = string(DOC_TYPE_EVENT)
+	for _, field := range []string{"drawTypes", "prizes", "exclusionRules", "authParams"} {
+		emptyIfNull(record, field)
+	}
+
+	prizes, _ := record["prizes"].([]interface{})
+	for _, item := range prizes {
+		prize, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"winners", "alternates", "disqualifications"} {
+			emptyIfNull(prize, field)
+		}
+
+		if record["status"] != string(STATUS_DRAWN) || prize["claims"] != nil {
+			emptyIfNull(prize, "claims")
+			continue
+		}
+		claims := make([]interface{}, 0)
+		for _, winner := range prize["winners"].([]interface{}) {
+			winnerObject, _ := winner.(map[string]interface{})
+			claims = append(claims, map[string]interface{}{
+				"participantUUID": winnerObject["UUID"],
+				"status":          string(CLAIM_PENDING),
+				"deadline":        0,
+				"statusTx":        record["drawTx"],
+			})
+		}
+		prize["claims"] = claims
+	}
+	return nil
+}
+
+// upgradeEventV1 adds the participant counter. the participants are not in the event record,
+// so the counter is left unknown and LoadEventHeaderByUUID counts them.
+func upgradeEventV1(record map[string]interface{}) error {
+	record["participantNum"] = -1
+	return nil
+}
+
+// upgradeEventV2 marks the participants not indexed by ordinal. they are drawn in key order until migrateEvents indexes them.
+// the running hash of the participants starts empty, and is chained by the next participation.
+func upgradeEventV2(record map[string]interface{}) error {
+	record["ordinalIndexed"] = false
+	record["participantsHash"] = ""
+	return nil
+}
+
+// upgradeEventV3 adds the escrow of paid events and the removal transaction. events written before are free.
+func upgradeEventV3(record map[string]interface{}) error {
+	record["escrow"] = map[string]interface{}{
+		"tokenChaincode": "",
+		"tokenChannel":   "",
+		"entryFee":       0,
+		"prizePool":      0,
+		"balance":        0,
+		"released":       false,
+	}
+	record["removeTx"] = map[string]interface{}{
+		"ID":               "",
+		"submitterId":      "",
+		"submitterAddress": "",
+		"timestamp":        0,
+		"clientID":         "",
+	}
+
+	prizes, _ := record["prizes"].([]interface{})
+	for _, item := range prizes {
+		if prize, ok := item.(map[string]interface{}); ok {
+			prize["payout"] = 0
+		}
+	}
+	return nil
+}
+
+// upgradeEventV4 adds the eligibility rules, events written before admit every participant.
+func upgradeEventV4(record map[string]interface{}) error {
+	record["eligibilityRules"] = make([]interface{}, 0)
+	return nil
+}
+
+// upgradeEventV5 adds the allowlist of invite-only events, events written before are open.
+func upgradeEventV5(record map[string]interface{}) error {
+	record["allowlistRoot"] = ""
+	record["allowlistLeaf"] = ""
+	record["allowlistTx"] = map[string]interface{}{
+		"ID":               "",
+		"submitterId":      "",
+		"submitterAddress": "",
+		"timestamp":        0,
+		"clientID":         "",
+	}
+	return nil
+}
+
+// upgradeParticipantV1 adds the ordinal, unknown until migrateEvents indexes the event.
+func upgradeParticipantV1(record map[string]interface{}) error {
+	record["ordinal"] = -1
+	return nil
+}
+
+func emptyIfNull(object map[string]interface{}, field string) {
+	if object[field] == nil {
+		object[field] = make([]interface{}, 0)
+	}
+}
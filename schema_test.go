@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// recordTypes returns a new value of the Go type of each doc type.
+var recordTypes = map[DocType]func() interface{}{
+	DOC_TYPE_CONFIG:              func() interface{} { return &LotteryConfig{} },
+	DOC_TYPE_DRAW_STATE:          func() interface{} { return &DrawState{} },
+	DOC_TYPE_EVENT:               func() interface{} { return &Event{} },
+	DOC_TYPE_ERASURE_RECEIPT:     func() interface{} { return &ErasureReceipt{} },
+	DOC_TYPE_PARTICIPANT:         func() interface{} { return &Participant{} },
+	DOC_TYPE_PARTICIPANT_PRIVATE: func() interface{} { return &ParticipantPrivateData{} },
+	DOC_TYPE_ROUND:               func() interface{} { return &Round{} },
+	DOC_TYPE_TEMPLATE:            func() interface{} { return &LotteryTemplate{} },
+	DOC_TYPE_TEMPLATE_VERSION:    func() interface{} { return &LotteryTemplate{} },
+	DOC_TYPE_WIN_RECORD:          func() interface{} { return &WinRecord{} },
+}
+
+// readFixture reads testdata/schema/{docType}_v{version}[_{case}].json
+func readFixture(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "schema", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// every version of every doc type has a fixture, and every fixture is upgraded into the current Go type.
+func TestRecordFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "schema", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := make(map[string]bool)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		parts := strings.Split(name, "_")
+		docType := DocType(parts[0])
+		version, err := strconv.ParseInt(strings.TrimPrefix(parts[1], "v"), 10, 64)
+		if err != nil {
+			t.Fatal(name + " : " + err.Error())
+		}
+		newRecord, exist := recordTypes[docType]
+		if !exist {
+			t.Fatal(name + " : unknown doc type")
+		}
+		covered[string(docType)+"_v"+strconv.FormatInt(version, 10)] = true
+
+		b := readFixture(t, filepath.Base(file))
+		recordVersion, err := RecordSchemaVersion(b)
+		if err != nil {
+			t.Fatal(name + " : " + err.Error())
+		}
+		if recordVersion != version {
+			t.Fatal(name + " : schemaVersion is " + strconv.FormatInt(recordVersion, 10))
+		}
+
+		upgraded, err := UpgradeRecord(docType, b)
+		if err != nil {
+			t.Fatal(name + " : " + err.Error())
+		}
+		if version == CurrentSchemaVersion(docType) && !bytes.Equal(upgraded, b) {
+			t.Fatal(name + " : current record is changed")
+		}
+
+		// the upgraded record has no field the current type does not know
+		record := newRecord()
+		decoder := json.NewDecoder(bytes.NewReader(upgraded))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(record); err != nil {
+			t.Fatal(name + " : " + err.Error())
+		}
+
+		header := struct {
+			DocType       DocType `json:"docType"`
+			SchemaVersion int64   `json:"schemaVersion"`
+		}{}
+		json.Unmarshal(upgraded, &header)
+		if header.DocType != docType || header.SchemaVersion != CurrentSchemaVersion(docType) {
+			t.Fatal(name + " : upgraded to " + string(header.DocType) + " version " + strconv.FormatInt(header.SchemaVersion, 10))
+		}
+	}
+
+	for docType := range recordUpgrades {
+		for version := int64(0); version <= CurrentSchemaVersion(docType); version++ {
+			if !covered[string(docType)+"_v"+strconv.FormatInt(version, 10)] {
+				t.Error("no fixture of " + string(docType) + " version " + strconv.FormatInt(version, 10))
+			}
+		}
+	}
+}
+
+func TestUpgradeEventV0(t *testing.T) {
+	event := &Event{}
+	if err := UnmarshalRecord(DOC_TYPE_EVENT, readFixture(t, "event_v0_drawn.json"), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.DocType != DOC_TYPE_EVENT || event.SchemaVersion != CurrentSchemaVersion(DOC_TYPE_EVENT) {
+		t.Fatal("event is not upgraded")
+	}
+	if event.ExclusionRules == nil || event.AuthParams == nil || event.Prizes[0].Alternates == nil || event.Prizes[0].Disqualifications == nil {
+		t.Fatal("null lists are not replaced")
+	}
+
+	// claims are opened for the winners of the old draw
+	claims := event.Prizes[0].Claims
+	if len(claims) != 1 || claims[0].ParticipantUUID != event.Prizes[0].Winners[0].UUID || claims[0].Status != CLAIM_PENDING {
+		t.Fatal("claims are not opened")
+	}
+	if claims[0].StatusTx != event.DrawTx || claims[0].Deadline != 0 {
+		t.Fatal("claim is not made by the draw transaction")
+	}
+
+	// registered events have no claims
+	event = &Event{}
+	if err := UnmarshalRecord(DOC_TYPE_EVENT, readFixture(t, "event_v0.json"), event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Prizes[0].Claims == nil || len(event.Prizes[0].Claims) != 0 || event.Prizes[0].Winners == nil {
+		t.Fatal("registered event prizes are not upgraded")
+	}
+	if event.TargetBlock.Height != 620000 || event.EventCreateTx.Timestamp != 1580000000 {
+		t.Fatal("numbers are changed")
+	}
+}
+
+func TestUnsupportedSchemaVersion(t *testing.T) {
+	b := []byte(`{"docType":"event","schemaVersion":` + strconv.FormatInt(CurrentSchemaVersion(DOC_TYPE_EVENT)+1, 10) + `}`)
+	err := UnmarshalRecord(DOC_TYPE_EVENT, b, &Event{})
+	if !ErrUnsupportedSchemaVersion.Is(err) {
+		t.Fatal("newer record is read")
+	}
+}
+
+func TestSaveCurrentSchemaVersion(t *testing.T) {
+	b, err := Event{}.ToLedgerBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _ := RecordSchemaVersion(b)
+	if version != CurrentSchemaVersion(DOC_TYPE_EVENT) {
+		t.Fatal("event is not saved in the current version")
+	}
+
+	// the version does not take part in the commitment
+	privateData := ParticipantPrivateData{Information: "alice@example.com", Salt: "salt"}
+	commitment, _ := privateData.MakeCommitment()
+	privateData.DocType = DOC_TYPE_PARTICIPANT_PRIVATE
+	privateData.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_PARTICIPANT_PRIVATE)
+	if versioned, _ := privateData.MakeCommitment(); versioned != commitment {
+		t.Fatal("commitment is changed by the schema version")
+	}
+}
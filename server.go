@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// environment variables of the chaincode-as-a-service launch mode.
+// the chaincode runs as a gRPC server when CHAINCODE_SERVER_ADDRESS is set, otherwise it is launched by the peer.
+const (
+	ENV_SERVER_ADDRESS = "CHAINCODE_SERVER_ADDRESS" // listen address, e.g. 0.0.0.0:9999
+	ENV_CCID           = "CHAINCODE_ID"             // package ID of the installed chaincode
+	ENV_TLS_DISABLED   = "CHAINCODE_TLS_DISABLED"   // "false" enables TLS, disabled by default like the fabric samples
+	ENV_TLS_KEY        = "CHAINCODE_TLS_KEY"        // path of the PEM private key
+	ENV_TLS_CERT       = "CHAINCODE_TLS_CERT"       // path of the PEM certificate
+	ENV_CLIENT_CA_CERT = "CHAINCODE_CLIENT_CA_CERT" // path of the PEM CA certificate of peers, optional
+)
+
+// IsServerMode reports whether the chaincode is launched as an external service.
+func IsServerMode() bool {
+	return os.Getenv(ENV_SERVER_ADDRESS) != ""
+}
+
+// NewChaincodeServer returns the chaincode server configured by the environment variables.
+func NewChaincodeServer(cc shim.Chaincode) (*shim.ChaincodeServer, error) {
+	address := os.Getenv(ENV_SERVER_ADDRESS)
+	if address == "" {
+		return nil, errors.New(ENV_SERVER_ADDRESS + " is not set")
+	}
+	ccid := os.Getenv(ENV_CCID)
+	if ccid == "" {
+		return nil, errors.New(ENV_CCID + " is not set")
+	}
+
+	tlsProps, err := loadTLSProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	return &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       cc,
+		TLSProps: tlsProps,
+	}, nil
+}
+
+func loadTLSProperties() (shim.TLSProperties, error) {
+	if strings.ToLower(os.Getenv(ENV_TLS_DISABLED)) != "false" {
+		return shim.TLSProperties{Disabled: true}, nil
+	}
+
+	key, err := readEnvFile(ENV_TLS_KEY, true)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+	cert, err := readEnvFile(ENV_TLS_CERT, true)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+	clientCACerts, err := readEnvFile(ENV_CLIENT_CA_CERT, false)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+
+	return shim.TLSProperties{
+		Key:           key,
+		Cert:          cert,
+		ClientCACerts: clientCACerts,
+	}, nil
+}
+
+// readEnvFile reads the file at the path of the environment variable.
+func readEnvFile(name string, required bool) ([]byte, error) {
+	path := os.Getenv(name)
+	if path == "" {
+		if required {
+			return nil, errors.New(name + " is not set")
+		}
+		return nil, nil
+	}
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setServerEnv(env map[string]string) {
+	for _, name := range []string{ENV_SERVER_ADDRESS, ENV_CCID, ENV_TLS_DISABLED, ENV_TLS_KEY, ENV_TLS_CERT, ENV_CLIENT_CA_CERT} {
+		os.Unsetenv(name)
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+}
+
+func TestNewChaincodeServer(t *testing.T) {
+	defer setServerEnv(nil)
+
+	setServerEnv(nil)
+	if IsServerMode() {
+		t.Error("peer launch mode is expected without server address")
+	}
+
+	setServerEnv(map[string]string{ENV_SERVER_ADDRESS: "0.0.0.0:9999"})
+	if _, err := NewChaincodeServer(new(LotteryChaincode)); err == nil {
+		t.Error("CCID must be required")
+	}
+
+	setServerEnv(map[string]string{ENV_SERVER_ADDRESS: "0.0.0.0:9999", ENV_CCID: "lottery:abc"})
+	server, err := NewChaincodeServer(new(LotteryChaincode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsServerMode() || server.CCID != "lottery:abc" || server.Address != "0.0.0.0:9999" || !server.TLSProps.Disabled {
+		t.Errorf("unexpected server : %+v", server)
+	}
+
+	dir, err := ioutil.TempDir("", "lottery_tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "key.pem"), []byte("key"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte("cert"), 0600)
+
+	setServerEnv(map[string]string{ENV_SERVER_ADDRESS: "0.0.0.0:9999", ENV_CCID: "lottery:abc", ENV_TLS_DISABLED: "false", ENV_TLS_KEY: filepath.Join(dir, "key.pem")})
+	if _, err = NewChaincodeServer(new(LotteryChaincode)); err == nil {
+		t.Error("TLS certificate must be required when TLS is enabled")
+	}
+
+	os.Setenv(ENV_TLS_CERT, filepath.Join(dir, "cert.pem"))
+	server, err = NewChaincodeServer(new(LotteryChaincode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.TLSProps.Disabled || string(server.TLSProps.Key) != "key" || string(server.TLSProps.Cert) != "cert" || server.TLSProps.ClientCACerts != nil {
+		t.Errorf("unexpected TLS properties : %+v", server.TLSProps)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+// LoadEventHeaderByUUID loads the event without its participants.
+// events recorded before the participant counter have their participants counted here.
+func LoadEventHeaderByUUID(stubInterface shim.ChaincodeStubInterface, UUID string) (*Event, error) {
+	event, err := loadEventForList(stubInterface, UUID, true)
+	if err != nil {
+		return nil, err
+	}
+	if event.UUID == "" || event.ParticipantNum >= 0 {
+		return event, nil
+	}
+
+	event.ParticipantNum, err = CountParticipants(stubInterface, UUID)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// CountParticipants counts the participant records of the event without decoding them.
+func CountParticipants(stubInterface shim.ChaincodeStubInterface, eventUUID string) (int64, error) {
+	participantIterator, err := stubInterface.GetStateByPartialCompositeKey(MakeKeyByUUID(eventUUID), []string{"participants"})
+	if err != nil {
+		return 0, err
+	}
+	defer participantIterator.Close()
+
+	count := int64(0)
+	for participantIterator.HasNext() {
+		_, err = participantIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// LoadParticipantsAt loads the participants at the ordinals of the draw order.
+// indexed events read the ordinal index and the requested records only.
+func LoadParticipantsAt(stubInterface shim.ChaincodeStubInterface, event *Event, ordinals []int) (map[int]Participant, error) {
+	if !event.OrdinalIndexed {
+		return loadParticipantsInKeyOrder(stubInterface, event.UUID, ordinals)
+	}
+
+	participants := make(map[int]Participant, len(ordinals))
+	for _, ordinal := range ordinals {
+		ordinalKey, err := MakeOrdinalKey(stubInterface, event.UUID, int64(ordinal))
+		if err != nil {
+			return nil, err
+		}
+		participantUUID, err := stubInterface.GetState(ordinalKey)
+		if err != nil {
+			return nil, err
+		}
+		if participantUUID == nil {
+			return nil, ErrParticipantNotFound.WithDetail("ordinal " + strconv.Itoa(ordinal))
+		}
+
+		key, err := MakeParticipantKey(stubInterface, event.UUID, string(participantUUID))
+		if err != nil {
+			return nil, err
+		}
+		b, err := stubInterface.GetState(key)
+		if err != nil {
+			return nil, err
+		}
+		p := Participant{}
+		err = UnmarshalRecord(DOC_TYPE_PARTICIPANT, b, &p)
+		if err != nil {
+			return nil, err
+		}
+		participants[ordinal] = p
+	}
+	return participants, nil
+}
+
+// loadParticipantsInKeyOrder loads the participants at the ordinals of the participant key order, the draw order of events not indexed yet.
+// records are read up to the last ordinal, and only the requested ones are decoded.
+func loadParticipantsInKeyOrder(stubInterface shim.ChaincodeStubInterface, eventUUID string, ordinals []int) (map[int]Participant, error) {
+	participants := make(map[int]Participant, len(ordinals))
+	if len(ordinals) == 0 {
+		return participants, nil
+	}
+
+	wanted := make(map[int]bool, len(ordinals))
+	lastOrdinal := 0
+	for _, ordinal := range ordinals {
+		wanted[ordinal] = true
+		if ordinal > lastOrdinal {
+			lastOrdinal = ordinal
+		}
+	}
+
+	participantIterator, err := stubInterface.GetStateByPartialCompositeKey(MakeKeyByUUID(eventUUID), []string{"participants"})
+	if err != nil {
+		return nil, err
+	}
+	defer participantIterator.Close()
+
+	for ordinal := 0; ordinal <= lastOrdinal && participantIterator.HasNext(); ordinal++ {
+		kv, err := participantIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !wanted[ordinal] {
+			continue
+		}
+
+		p := Participant{}
+		err = UnmarshalRecord(DOC_TYPE_PARTICIPANT, kv.Value, &p)
+		if err != nil {
+			return nil, err
+		}
+		participants[ordinal] = p
+	}
+	return participants, nil
+}
+
+// DrawStreaming draws the same result as Draw without the participants in memory.
+// only the shuffled positions taking a prize slot are traced ( draw.ShufflePrefix ),
+// and only the participants at those positions are loaded.
+func (e *Event) DrawStreaming(stubInterface shim.ChaincodeStubInterface, tx Transaction) error {
+	err := e.startDraw(tx)
+	if err != nil {
+		return err
+	}
+
+	drawingNum := e.ParticipantNum
+	if drawingNum > e.MaxParticipant {
+		drawingNum = e.MaxParticipant
+	}
+	ordinals := draw.ShufflePrefix(int(drawingNum), int(prizeSlotNum(e.Prizes)), e.SeedHash)
+
+	participants, err := LoadParticipantsAt(stubInterface, e, ordinals)
+	if err != nil {
+		return err
+	}
+	shuffledParticipant := make([]Participant, len(ordinals))
+	for idx, ordinal := range ordinals {
+		shuffledParticipant[idx] = participants[ordinal]
+	}
+
+	awardPrizes(e.Prizes, shuffledParticipant, tx)
+	return nil
+}
+
+// prizeSlotNum returns the number of winners and alternates of the prizes.
+func prizeSlotNum(prizes []Prize) int64 {
+	num := int64(0)
+	for _, prize := range prizes {
+		num += prize.WinnerNum + prize.AlternateNum
+	}
+	return num
+}
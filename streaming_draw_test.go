@@ -0,0 +1,188 @@
+package main
+
+import (
+	"container/list"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// newDrawStub records an event and its participants directly in the mock state.
+// MockStub.PutState keeps the keys in a sorted list, too slow for a million participants.
+// indexed events have the participant ordinals in the participation order, which is not the key order.
+func newDrawStub(tb testing.TB, participantNum int, indexed bool) (*shimtest.MockStub, *Event) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+	event := &Event{
+		UUID:           "drawEvent",
+		Status:         STATUS_REGISTERD,
+		DeadlineTime:   100,
+		MaxParticipant: int64(participantNum),
+		ParticipantNum: int64(participantNum),
+		OrdinalIndexed: indexed,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes: []Prize{
+			{UUID: "first", WinnerNum: 1, AlternateNum: 2},
+			{UUID: "second", WinnerNum: 10, AlternateNum: 5},
+		},
+		ServiceProviderHash: "providerHash",
+	}
+
+	keys := make([]string, 0, participantNum+1)
+	b, err := event.ToLedgerBinary()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	m.State[event.GetKey()] = b
+	keys = append(keys, event.GetKey())
+	for i := 0; i < participantNum; i++ {
+		participant := Participant{UUID: "participant" + strconv.Itoa(i), Commitment: "commitment" + strconv.Itoa(i), Ordinal: -1}
+		if indexed {
+			participant.Ordinal = int64(i)
+			ordinalKey, err := MakeOrdinalKey(m, event.UUID, participant.Ordinal)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			m.State[ordinalKey] = []byte(participant.UUID)
+			keys = append(keys, ordinalKey)
+		}
+
+		key, err := MakeParticipantKey(m, event.UUID, participant.UUID)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		m.State[key], err = participant.ToLedgerBinary()
+		if err != nil {
+			tb.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	m.Keys = list.New()
+	for _, key := range keys {
+		m.Keys.PushBack(key)
+	}
+	return m, event
+}
+
+func TestDrawStreaming(t *testing.T) {
+	for _, participantNum := range []int{0, 5, 40} {
+		for _, indexed := range []bool{false, true} {
+			testDrawStreaming(t, participantNum, indexed)
+		}
+	}
+}
+
+func testDrawStreaming(t *testing.T, participantNum int, indexed bool) {
+	m, _ := newDrawStub(t, participantNum, indexed)
+	tx := Transaction{ID: "drawTx", Timestamp: 200}
+
+	expected, err := LoadEventByUUID(m, "drawEvent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = expected.Draw(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := LoadEventHeaderByUUID(m, "drawEvent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(event.Participants) != 0 {
+		t.Fatal("participants are loaded")
+	}
+	if err = event.DrawStreaming(m, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, prize := range event.Prizes {
+		expectedPrize := expected.Prizes[idx]
+		if !sameParticipants(prize.Winners, expectedPrize.Winners) || !sameParticipants(prize.Alternates, expectedPrize.Alternates) {
+			t.Fatalf("%d participants, indexed %t : prize %s is drawn differently", participantNum, indexed, prize.UUID)
+		}
+		if len(prize.Claims) != len(expectedPrize.Claims) {
+			t.Fatalf("%d participants, indexed %t : claims of prize %s are not opened", participantNum, indexed, prize.UUID)
+		}
+	}
+	if event.SeedHash != expected.SeedHash || event.Status != STATUS_DRAWN {
+		t.Fatal("seed is not fixed")
+	}
+}
+
+func sameParticipants(a []Participant, b []Participant) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx].UUID != b[idx].UUID || a[idx].Commitment != b[idx].Commitment {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLoadEventHeaderCountsParticipants(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+	event := &Event{}
+	unmarshalFixture(t, "event_v1.json", event)
+
+	m.MockTransactionStart("legacyTx")
+	m.PutState(event.GetKey(), readFixture(t, "event_v1.json"))
+	for _, uuid := range []string{"participant1", "participant2", "participant3"} {
+		key, _ := MakeParticipantKey(m, event.UUID, uuid)
+		b, _ := Participant{UUID: uuid}.ToLedgerBinary()
+		m.PutState(key, b)
+	}
+	m.MockTransactionEnd("legacyTx")
+
+	header, err := LoadEventHeaderByUUID(m, event.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.ParticipantNum != 3 {
+		t.Fatal("participants of the old event are not counted : " + strconv.FormatInt(header.ParticipantNum, 10))
+	}
+}
+
+var benchmarkParticipantNums = []int{10000, 100000, 1000000}
+
+// BenchmarkDraw loads every participant and shuffles them in memory.
+func BenchmarkDraw(b *testing.B) {
+	for _, participantNum := range benchmarkParticipantNums {
+		m, _ := newDrawStub(b, participantNum, true)
+		b.Run(strconv.Itoa(participantNum), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				event, err := LoadEventByUUID(m, "drawEvent")
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = event.Draw(Transaction{ID: "drawTx", Timestamp: 200}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDrawStreaming traces the prize slots of the shuffle and loads the participants in them only.
+func BenchmarkDrawStreaming(b *testing.B) {
+	for _, participantNum := range benchmarkParticipantNums {
+		m, _ := newDrawStub(b, participantNum, true)
+		b.Run(strconv.Itoa(participantNum), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				event, err := LoadEventHeaderByUUID(m, "drawEvent")
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = event.DrawStreaming(m, Transaction{ID: "drawTx", Timestamp: 200}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// RecurrenceRule derives the deadline and the target block height of every spawned event.
+// occurrence n has deadline StartTime + n * IntervalSeconds and target block StartBlockHeight + n * BlockHeightInterval
+type RecurrenceRule struct {
+	StartTime           int64 `json:"startTime" metadata:",optional"`           // deadline of the first occurrence, UNIX timestamp
+	IntervalSeconds     int64 `json:"intervalSeconds"`                          // seconds between deadlines
+	StartBlockHeight    int64 `json:"startBlockHeight" metadata:",optional"`    // target block height of the first occurrence
+	BlockHeightInterval int64 `json:"blockHeightInterval" metadata:",optional"` // blocks between target blocks
+	MaxOccurrences      int64 `json:"maxOccurrences" metadata:",optional"`      // 0 means unlimited
+}
+
+type LotteryTemplate struct {
+	DocType        DocType         `json:"docType"`
+	SchemaVersion  int64           `json:"schemaVersion"`
+	UUID           string          `json:"UUID"`
+	Version        int64           `json:"version"`
+	Name           string          `json:"name"`
+	Contents       string          `json:"contents"`
+	MaxParticipant int64           `json:"maxParticipant"`
+	DrawTypes      []DrawType      `json:"drawTypes"`
+	Prizes         []Prize         `json:"prizes"`
+	ExclusionRules []ExclusionRule `json:"exclusionRules"`
+	BlockType      BlockType       `json:"blockType"`
+	AuthURL        string          `json:"authURL"`
+	AuthParams     []string        `json:"authParams"`
+	Recurrence     RecurrenceRule  `json:"recurrence"`
+
+	// number of spawned occurrences, including skipped ones
+	SpawnCount int64 `json:"spawnCount"`
+
+	// transaction info
+	TemplateCreateTx Transaction `json:"templateCreateTx"`
+	TemplateUpdateTx Transaction `json:"templateUpdateTx"`
+}
+
+func NewLotteryTemplate(request *CreateLotteryTemplateRequest, createTemplateTx Transaction) LotteryTemplate {
+	return LotteryTemplate{
+		DocType:          DOC_TYPE_TEMPLATE,
+		SchemaVersion:    CurrentSchemaVersion(DOC_TYPE_TEMPLATE),
+		UUID:             createTemplateTx.MakeUUID(0),
+		Version:          1,
+		Name:             request.Name,
+		Contents:         request.Contents,
+		MaxParticipant:   request.MaxParticipant,
+		DrawTypes:        request.DrawTypes,
+		Prizes:           request.Prizes,
+		ExclusionRules:   request.ExclusionRules,
+		BlockType:        request.BlockType,
+		AuthURL:          request.AuthURL,
+		AuthParams:       request.AuthParams,
+		Recurrence:       request.Recurrence,
+		SpawnCount:       0,
+		TemplateCreateTx: createTemplateTx,
+		TemplateUpdateTx: createTemplateTx,
+	}
+}
+
+func (t *LotteryTemplate) GetKey() string {
+	return MakeTemplateKeyByUUID(t.UUID)
+}
+
+// SaveToLedger saves the current template and archives the version in composite key ( template_UUID_{UUID}~versions~{version} )
+func (t *LotteryTemplate) SaveToLedger(stubInterface shim.ChaincodeStubInterface) error {
+	t.DocType = DOC_TYPE_TEMPLATE
+	t.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_TEMPLATE)
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	err = stubInterface.PutState(t.GetKey(), b)
+	if err != nil {
+		return err
+	}
+
+	versionKey, err := stubInterface.CreateCompositeKey(t.GetKey(), []string{"versions", fmt.Sprintf("%08d", t.Version)})
+	if err != nil {
+		return err
+	}
+
+	// spawn count changes do not make a new version
+	versionData := *t
+	versionData.DocType = DOC_TYPE_TEMPLATE_VERSION
+	versionData.SchemaVersion = CurrentSchemaVersion(DOC_TYPE_TEMPLATE_VERSION)
+	versionData.SpawnCount = 0
+	vb, err := json.Marshal(versionData)
+	if err != nil {
+		return err
+	}
+	return stubInterface.PutState(versionKey, vb)
+}
+
+// Update replaces the template contents and raises the version.
+func (t *LotteryTemplate) Update(request *CreateLotteryTemplateRequest, updateTemplateTx Transaction) {
+	t.Version++
+	t.Name = request.Name
+	t.Contents = request.Contents
+	t.MaxParticipant = request.MaxParticipant
+	t.DrawTypes = request.DrawTypes
+	t.Prizes = request.Prizes
+	t.ExclusionRules = request.ExclusionRules
+	t.BlockType = request.BlockType
+	t.AuthURL = request.AuthURL
+	t.AuthParams = request.AuthParams
+	t.Recurrence = request.Recurrence
+	t.TemplateUpdateTx = updateTemplateTx
+}
+
+// NextOccurrence returns the first occurrence whose deadline is after txTimestamp.
+func (t *LotteryTemplate) NextOccurrence(txTimestamp int64) (int64, error) {
+	occurrence := t.SpawnCount
+	if t.Recurrence.IntervalSeconds <= 0 {
+		return 0, ErrInvalidArg.WithField("recurrence").WithDetail("recurrence interval is not set")
+	}
+
+	// skip occurrences whose deadline is already passed
+	if deadline := t.Recurrence.StartTime + occurrence*t.Recurrence.IntervalSeconds; deadline <= txTimestamp {
+		occurrence += (txTimestamp-deadline)/t.Recurrence.IntervalSeconds + 1
+	}
+
+	if t.Recurrence.MaxOccurrences != 0 && occurrence >= t.Recurrence.MaxOccurrences {
+		return 0, ErrNoMoreOccurrence
+	}
+	return occurrence, nil
+}
+
+// MakeCreateLotteryRequest fills an event creation request for the occurrence.
+func (t *LotteryTemplate) MakeCreateLotteryRequest(occurrence int64, serviceProviderHash string, tx Transaction) *CreateLotteryRequest {
+	prizes := make([]Prize, len(t.Prizes))
+	copy(prizes, t.Prizes)
+
+	return &CreateLotteryRequest{
+		EventName:        t.Name,
+		Contents:         t.Contents,
+		DeadlineTime:     t.Recurrence.StartTime + occurrence*t.Recurrence.IntervalSeconds,
+		MaxParticipant:   t.MaxParticipant,
+		DrawTypes:        t.DrawTypes,
+		Prizes:           prizes,
+		ExclusionRules:   t.ExclusionRules,
+		SubmitterID:      tx.SubmitterID,
+		SubmitterAddress: tx.SubmitterAddress,
+		AuthURL:          t.AuthURL,
+		AuthParams:       t.AuthParams,
+		TargetBlock: BlockInfo{
+			BlockType: t.BlockType,
+			Height:    t.Recurrence.StartBlockHeight + occurrence*t.Recurrence.BlockHeightInterval,
+		},
+		ServiceProviderHash: serviceProviderHash,
+	}
+}
+
+func LoadTemplateByUUID(stubInterface shim.ChaincodeStubInterface, UUID string) (*LotteryTemplate, error) {
+	b, err := stubInterface.GetState(MakeTemplateKeyByUUID(UUID))
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return &LotteryTemplate{}, nil
+	}
+
+	template := &LotteryTemplate{}
+	err = UnmarshalRecord(DOC_TYPE_TEMPLATE, b, template)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func LoadTemplateVersion(stubInterface shim.ChaincodeStubInterface, UUID string, version int64) (*LotteryTemplate, error) {
+	versionKey, err := stubInterface.CreateCompositeKey(MakeTemplateKeyByUUID(UUID), []string{"versions", fmt.Sprintf("%08d", version)})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := stubInterface.GetState(versionKey)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return &LotteryTemplate{}, nil
+	}
+
+	template := &LotteryTemplate{}
+	err = UnmarshalRecord(DOC_TYPE_TEMPLATE_VERSION, b, template)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func MakeTemplateKeyByUUID(UUID string) string {
+	return "template_UUID_" + UUID
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpawnFromTemplate(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	startTime := time.Now().Unix() + 1000
+	createRequest := CreateLotteryTemplateRequest{
+		Name:           "daily lottery",
+		MaxParticipant: 100,
+		DrawTypes:      []DrawType{DRAW_BLOCK_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+		BlockType:      BITCOIN,
+		Recurrence: RecurrenceRule{
+			StartTime:           startTime,
+			IntervalSeconds:     86400,
+			StartBlockHeight:    592122,
+			BlockHeightInterval: 144,
+			MaxOccurrences:      2,
+		},
+	}
+	payload, ok := m.call(t, "createTemplateTx", "createLotteryTemplate", createRequest)
+	if !ok {
+		t.FailNow()
+	}
+	template := &LotteryTemplate{}
+	if err := unmarshalData(payload, template); err != nil {
+		t.Fatal(err)
+	}
+
+	updateRequest := UpdateLotteryTemplateRequest{TemplateUUID: template.UUID, CreateLotteryTemplateRequest: createRequest}
+	updateRequest.Contents = "updated contents"
+	spawnRequest := SpawnFromTemplateRequest{TemplateUUID: template.UUID}
+
+	// only the template creator updates the template and spawns events
+	m.setClient(t, "other")
+	if _, ok = m.call(t, "updateTemplateTx", "updateLotteryTemplate", updateRequest); ok {
+		t.Fatal("other client updated the template")
+	}
+	if _, ok = m.call(t, "spawnTx", "spawnFromTemplate", spawnRequest); ok {
+		t.Fatal("other client spawned an event")
+	}
+
+	m.setClient(t, "provider")
+	if _, ok = m.call(t, "updateTemplateTx", "updateLotteryTemplate", updateRequest); !ok {
+		t.FailNow()
+	}
+
+	for occurrence := int64(0); occurrence < 2; occurrence++ {
+		payload, ok = m.call(t, "spawnTx", "spawnFromTemplate", spawnRequest)
+		if !ok {
+			t.FailNow()
+		}
+
+		event := &Event{}
+		if err := unmarshalData(payload, event); err != nil {
+			t.Fatal(err)
+		}
+		if event.TemplateUUID != template.UUID || event.TemplateVersion != 2 || event.TemplateOccurrence != occurrence {
+			t.Errorf("unexpected template info : %s %d %d", event.TemplateUUID, event.TemplateVersion, event.TemplateOccurrence)
+		}
+		if event.DeadlineTime != startTime+occurrence*86400 {
+			t.Errorf("unexpected deadline : %d", event.DeadlineTime)
+		}
+		if event.TargetBlock.Height != 592122+occurrence*144 {
+			t.Errorf("unexpected target block height : %d", event.TargetBlock.Height)
+		}
+		if event.Contents != "updated contents" {
+			t.Errorf("unexpected contents : %s", event.Contents)
+		}
+	}
+
+	if _, ok = m.call(t, "spawnTx", "spawnFromTemplate", spawnRequest); ok {
+		t.Error("template must not spawn more than max occurrences")
+	}
+}
+
+func TestSpawnFromTemplateValidation(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+
+	// the claim deadline is fixed, so only the first occurrence is drawn before it
+	startTime := time.Now().Unix() + 1000
+	// spawned events have no escrow to pay out from
+	if _, ok := m.call(t, "createTemplateTx", "createLotteryTemplate", CreateLotteryTemplateRequest{
+		Name:           "daily lottery",
+		MaxParticipant: 100,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1, Payout: 10}},
+		Recurrence:     RecurrenceRule{StartTime: startTime, IntervalSeconds: 86400},
+	}); ok {
+		t.Fatal("template with payouts is created")
+	}
+
+	payload, ok := m.call(t, "createTemplateTx", "createLotteryTemplate", CreateLotteryTemplateRequest{
+		Name:           "daily lottery",
+		MaxParticipant: 100,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1, ClaimDeadline: startTime + 3600}},
+		Recurrence:     RecurrenceRule{StartTime: startTime, IntervalSeconds: 86400},
+	})
+	if !ok {
+		t.FailNow()
+	}
+	template := &LotteryTemplate{}
+	unmarshalData(payload, template)
+
+	spawnRequest := SpawnFromTemplateRequest{TemplateUUID: template.UUID, ServiceProviderHash: "providerHash"}
+	if _, ok = m.call(t, "spawnTx1", "spawnFromTemplate", spawnRequest); !ok {
+		t.FailNow()
+	}
+	if _, ok = m.call(t, "spawnTx2", "spawnFromTemplate", spawnRequest); ok {
+		t.Fatal("event with a claim deadline before its deadline is spawned")
+	}
+	if _, ok = m.call(t, "spawnTx3", "spawnFromTemplate", SpawnFromTemplateRequest{TemplateUUID: template.UUID}); ok {
+		t.Fatal("event without the service provider hash is spawned")
+	}
+}
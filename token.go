@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ESCROW_ACCOUNT_PREFIX makes the token account of an event ( lottery~escrow~{eventUUID} ).
+// the token chaincode must let the lottery chaincode move the tokens of these accounts.
+const ESCROW_ACCOUNT_PREFIX = "lottery~escrow~"
+
+// functions of the token chaincode, called with InvokeChaincode.
+// transfer args : [ "transfer", from account, to account, amount ], accounts are client IDs or escrow accounts.
+const TOKEN_TRANSFER_FUNCTION = "transfer"
+
+// TokenEscrow is the token account of a paid event. the event is free if TokenChaincode is empty.
+type TokenEscrow struct {
+	TokenChaincode string `json:"tokenChaincode"`
+	TokenChannel   string `json:"tokenChannel"` // empty or the channel of the lottery
+	EntryFee       int64  `json:"entryFee"`     // paid by each participant, refunded on withdrawal and removal
+	PrizePool      int64  `json:"prizePool"`    // funded by the creator, the payouts of every winner
+	Balance        int64  `json:"balance"`      // tokens held by the escrow account
+	Released       bool   `json:"released"`     // the balance is paid out or refunded
+}
+
+func (e *Event) EscrowAccount() string {
+	return ESCROW_ACCOUNT_PREFIX + e.UUID
+}
+
+func (e *Event) isPaid() bool {
+	return e.Escrow.TokenChaincode != ""
+}
+
+// prizePool sums the payouts of every winner of the prizes, failing if the sum overflows.
+func prizePool(prizes []Prize) (int64, error) {
+	pool := int64(0)
+	for idx, prize := range prizes {
+		if prize.WinnerNum > 0 && prize.Payout > (math.MaxInt64-pool)/prize.WinnerNum {
+			return 0, ErrInvalidArg.WithField("prizes[" + strconv.Itoa(idx) + "].payout").WithDetail("prize pool overflows")
+		}
+		pool += prize.Payout * prize.WinnerNum
+	}
+	return pool, nil
+}
+
+// checkEscrowArgs checks the entry fee and the payouts have a token chaincode to be paid with.
+// the token chaincode must be on the channel of the lottery, chaincodes of other channels are invoked read-only.
+func checkEscrowArgs(stubInterface shim.ChaincodeStubInterface, request *CreateLotteryRequest) error {
+	if request.TokenChannel != "" && request.TokenChannel != stubInterface.GetChannelID() {
+		return ErrInvalidArg.WithField("tokenChannel").WithDetail("token chaincode must be on the channel of the lottery")
+	}
+	pool, err := prizePool(request.Prizes)
+	if err != nil {
+		return err
+	}
+	if request.TokenChaincode == "" && (request.EntryFee > 0 || pool > 0) {
+		return ErrRequired("tokenChaincode")
+	}
+	return nil
+}
+
+// transferTokens calls the token chaincode of the event. nothing is called for zero amounts.
+func (e *Event) transferTokens(stubInterface shim.ChaincodeStubInterface, from string, to string, amount int64) error {
+	if amount == 0 {
+		return nil
+	}
+	if from == "" || to == "" {
+		return ErrTokenTransfer.WithDetail("account of the client is unknown")
+	}
+
+	args := [][]byte{[]byte(TOKEN_TRANSFER_FUNCTION), []byte(from), []byte(to), []byte(strconv.FormatInt(amount, 10))}
+	response := stubInterface.InvokeChaincode(e.Escrow.TokenChaincode, args, e.Escrow.TokenChannel)
+	if response.Status != shim.OK {
+		return ErrTokenTransfer.WithDetail(response.Message)
+	}
+	return nil
+}
+
+// FundEscrow moves the prize pool from the creator to the escrow account of a new event.
+func (e *Event) FundEscrow(stubInterface shim.ChaincodeStubInterface) error {
+	if !e.isPaid() {
+		return nil
+	}
+	err := e.transferTokens(stubInterface, e.EventCreateTx.ClientID, e.EscrowAccount(), e.Escrow.PrizePool)
+	if err != nil {
+		return err
+	}
+	e.Escrow.Balance += e.Escrow.PrizePool
+	return nil
+}
+
+// PayEntryFee moves the entry fee from the participant to the escrow account.
+func (e *Event) PayEntryFee(stubInterface shim.ChaincodeStubInterface, participant *Participant) error {
+	if !e.isPaid() {
+		return nil
+	}
+	err := e.transferTokens(stubInterface, participant.ParticipateTx.ClientID, e.EscrowAccount(), e.Escrow.EntryFee)
+	if err != nil {
+		return err
+	}
+	e.Escrow.Balance += e.Escrow.EntryFee
+	return nil
+}
+
+// RefundEntryFee returns the entry fee to the participant.
+func (e *Event) RefundEntryFee(stubInterface shim.ChaincodeStubInterface, participant *Participant) error {
+	if !e.isPaid() {
+		return nil
+	}
+	err := e.transferTokens(stubInterface, e.EscrowAccount(), participant.ParticipateTx.ClientID, e.Escrow.EntryFee)
+	if err != nil {
+		return err
+	}
+	e.Escrow.Balance -= e.Escrow.EntryFee
+	return nil
+}
+
+// ReleaseEscrow pays the payout of each prize to its winners once the event is drawn.
+// payouts of prizes with fewer winners than WinnerNum and the entry fees go to the creator.
+func (e *Event) ReleaseEscrow(stubInterface shim.ChaincodeStubInterface) error {
+	if !e.isPaid() || e.Escrow.Released {
+		return nil
+	}
+	if e.Status != STATUS_DRAWN {
+		return ErrInvalidStatus.WithDetail("escrow is released once the event is drawn")
+	}
+
+	for _, prize := range e.Prizes {
+		for idx := range prize.Winners {
+			err := e.transferTokens(stubInterface, e.EscrowAccount(), prize.Winners[idx].ParticipateTx.ClientID, prize.Payout)
+			if err != nil {
+				return err
+			}
+			e.Escrow.Balance -= prize.Payout
+		}
+	}
+	return e.closeEscrow(stubInterface)
+}
+
+// RefundEscrow returns the entry fee of every participant and the prize pool of a removed event.
+// the event must be loaded with its participants.
+func (e *Event) RefundEscrow(stubInterface shim.ChaincodeStubInterface) error {
+	if !e.isPaid() || e.Escrow.Released {
+		return nil
+	}
+
+	for idx := range e.Participants {
+		if e.Participants[idx].Withdrawn {
+			continue
+		}
+		err := e.RefundEntryFee(stubInterface, &e.Participants[idx])
+		if err != nil {
+			return err
+		}
+	}
+	return e.closeEscrow(stubInterface)
+}
+
+// closeEscrow returns the rest of the balance to the creator.
+func (e *Event) closeEscrow(stubInterface shim.ChaincodeStubInterface) error {
+	err := e.transferTokens(stubInterface, e.EscrowAccount(), e.EventCreateTx.ClientID, e.Escrow.Balance)
+	if err != nil {
+		return err
+	}
+	e.Escrow.Balance = 0
+	e.Escrow.Released = true
+	return nil
+}
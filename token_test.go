@@ -0,0 +1,239 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/sslab-archive/block_lottery_cc/tokenmock"
+)
+
+func newTokenStub(m *identityStub) *shimtest.MockStub {
+	tokens := shimtest.NewMockStub("token_cc", new(tokenmock.Chaincode))
+	m.MockPeerChaincode("token_cc", tokens, "")
+	return tokens
+}
+
+func mint(t *testing.T, tokens *shimtest.MockStub, account string, amount int64) {
+	response := tokens.MockInvoke("mintTx", [][]byte{[]byte("mint"), []byte(account), []byte(strconv.FormatInt(amount, 10))})
+	if response.Status != shim.OK {
+		t.Fatal(response.Message)
+	}
+}
+
+func balance(t *testing.T, tokens *shimtest.MockStub, account string) int64 {
+	b, err := tokenmock.Balance(tokens, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func newPaidEvent(t *testing.T, m *identityStub, deadline int64) *Event {
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   deadline,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "first", WinnerNum: 2, Payout: 30}, {Title: "second", WinnerNum: 1, Payout: 10}},
+
+		ServiceProviderHash: "providerHash",
+		TokenChaincode:      "token_cc",
+		EntryFee:            5,
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+	return event
+}
+
+func TestPaidEvent(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	tokens := newTokenStub(m)
+
+	m.setClient(t, "provider")
+	creator := GetClientID(m)
+	if _, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1, Payout: 30}},
+
+		ServiceProviderHash: "providerHash",
+	}); ok {
+		t.Fatal("payouts without a token chaincode are accepted")
+	}
+	if _, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1, Payout: 30}},
+
+		ServiceProviderHash: "providerHash",
+		TokenChaincode:      "token_cc",
+	}); ok {
+		t.Fatal("prize pool is not funded by the creator")
+	}
+	// chaincodes of other channels are invoked read-only
+	err := checkEscrowArgs(m, &CreateLotteryRequest{TokenChaincode: "token_cc", TokenChannel: "otherchannel"})
+	if !ErrInvalidArg.Is(err) || err.(*LotteryError).Field != "tokenChannel" {
+		t.Fatal("token chaincode of another channel is accepted")
+	}
+
+	mint(t, tokens, creator, 100)
+	event := newPaidEvent(t, m, time.Now().Unix()+1)
+	if event.Escrow.PrizePool != 70 || event.Escrow.Balance != 70 || balance(t, tokens, event.EscrowAccount()) != 70 || balance(t, tokens, creator) != 30 {
+		t.Fatal("prize pool is not moved to the escrow")
+	}
+
+	participants := make(map[string]string)
+	for _, uuid := range []string{"participant1", "participant2", "participant3"} {
+		m.setClient(t, uuid)
+		participants[uuid] = GetClientID(m)
+		request := ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: uuid}}
+		if _, ok := m.call(t, "participateTx"+uuid, "participateLotteryEvent", request); ok {
+			t.Fatal("participant joined without paying the fee")
+		}
+		mint(t, tokens, participants[uuid], 5)
+		if _, ok := m.call(t, "participateTx"+uuid, "participateLotteryEvent", request); !ok {
+			t.FailNow()
+		}
+		if balance(t, tokens, participants[uuid]) != 0 {
+			t.Fatal("fee is not paid")
+		}
+	}
+
+	// withdrawn participants are refunded
+	if _, ok := m.call(t, "withdrawTx", "withdrawParticipation", WithdrawParticipationRequest{EventUUID: event.UUID, ParticipantUUID: "participant3"}); !ok {
+		t.FailNow()
+	}
+	if balance(t, tokens, participants["participant3"]) != 5 || balance(t, tokens, event.EscrowAccount()) != 80 {
+		t.Fatal("fee is not refunded")
+	}
+
+	m.setClient(t, "provider")
+	time.Sleep(2 * time.Second)
+	payload, ok := m.call(t, "drawTx", "drawLotteryEvent", DrawLotteryRequest{EventUUID: event.UUID, ServiceProviderHash: "providerHash"})
+	if !ok {
+		t.FailNow()
+	}
+	drawn := &Event{}
+	unmarshalData(payload, drawn)
+
+	// both participants win the first prize, the payout of the second one and the fees go back to the creator
+	if len(drawn.Prizes[0].Winners) != 2 || len(drawn.Prizes[1].Winners) != 0 {
+		t.Fatal("unexpected winners")
+	}
+	if balance(t, tokens, participants["participant1"]) != 30 || balance(t, tokens, participants["participant2"]) != 30 {
+		t.Fatal("payouts are not released to the winners")
+	}
+	if balance(t, tokens, creator) != 50 || balance(t, tokens, event.EscrowAccount()) != 0 {
+		t.Fatal("rest of the escrow is not returned to the creator")
+	}
+	if !drawn.Escrow.Released || drawn.Escrow.Balance != 0 {
+		t.Fatal("escrow is not closed")
+	}
+
+	if _, ok = m.call(t, "disqualifyTx", "disqualifyWinner", DisqualifyWinnerRequest{
+		EventUUID:       event.UUID,
+		PrizeUUID:       drawn.Prizes[0].UUID,
+		ParticipantUUID: drawn.Prizes[0].Winners[0].UUID,
+		Reason:          "fraud",
+	}); ok {
+		t.Fatal("winner paid by the draw is disqualified")
+	}
+}
+
+func TestPrizePoolOverflow(t *testing.T) {
+	pool, err := prizePool([]Prize{{WinnerNum: 2, Payout: 30}, {WinnerNum: 1, Payout: 10}})
+	if err != nil || pool != 70 {
+		t.Fatalf("prize pool is %d : %v", pool, err)
+	}
+
+	_, err = prizePool([]Prize{{WinnerNum: 2, Payout: math.MaxInt64 / 4}, {WinnerNum: 3, Payout: math.MaxInt64 / 4}})
+	if !ErrInvalidArg.Is(err) || err.(*LotteryError).Field != "prizes[1].payout" {
+		t.Fatalf("overflowing prize pool is summed : %v", err)
+	}
+}
+
+func TestRemovePaidEvent(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	tokens := newTokenStub(m)
+
+	m.setClient(t, "provider")
+	creator := GetClientID(m)
+	mint(t, tokens, creator, 70)
+	event := newPaidEvent(t, m, time.Now().Unix()+1000)
+
+	m.setClient(t, "participant1")
+	participant := GetClientID(m)
+	mint(t, tokens, participant, 5)
+	if _, ok := m.call(t, "participateTx", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "participant1"}}); !ok {
+		t.FailNow()
+	}
+
+	if _, ok := m.call(t, "removeTx", "removeLotteryEvent", RemoveLotteryRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("participant removed the event")
+	}
+
+	m.setClient(t, "provider")
+	payload, ok := m.call(t, "removeTx", "removeLotteryEvent", RemoveLotteryRequest{EventUUID: event.UUID})
+	if !ok {
+		t.FailNow()
+	}
+	removed := &Event{}
+	unmarshalData(payload, removed)
+	if removed.Status != STATUS_REMOVED || removed.RemoveTx.ID != "removeTx" {
+		t.Fatal("event is not removed")
+	}
+	if balance(t, tokens, participant) != 5 || balance(t, tokens, creator) != 70 || balance(t, tokens, event.EscrowAccount()) != 0 {
+		t.Fatal("escrow is not refunded")
+	}
+
+	if _, ok = m.call(t, "removeTx2", "removeLotteryEvent", RemoveLotteryRequest{EventUUID: event.UUID}); ok {
+		t.Fatal("event is removed twice")
+	}
+	m.setClient(t, "participant2")
+	if _, ok = m.call(t, "participateTx2", "participateLotteryEvent", ParticipateLotteryRequest{EventUUID: event.UUID, Participant: Participant{UUID: "participant2"}}); ok {
+		t.Fatal("participant joined a removed event")
+	}
+}
+
+func TestBulkParticipatePaidEvent(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	tokens := newTokenStub(m)
+
+	m.setClient(t, "provider")
+	mint(t, tokens, GetClientID(m), 30)
+	// a free entry does not open the event to rows, the payouts would go to the creator
+	payload, ok := m.call(t, "createTx", "createLotteryEvent", CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   time.Now().Unix() + 1000,
+		MaxParticipant: 10,
+		DrawTypes:      []DrawType{DRAW_SERVICE_PROVIDER_HASH},
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1, Payout: 30}},
+
+		ServiceProviderHash: "providerHash",
+		TokenChaincode:      "token_cc",
+	})
+	if !ok {
+		t.FailNow()
+	}
+	event := &Event{}
+	unmarshalData(payload, event)
+
+	if _, ok = m.call(t, "bulkTx", "bulkParticipate", BulkParticipateRequest{EventUUID: event.UUID, Participants: []Participant{{UUID: "p1"}}}); ok {
+		t.Fatal("rows are added to a paid event")
+	}
+	if loaded, _ := LoadEventByUUID(m, event.UUID); loaded.ParticipantNum != 0 {
+		t.Fatal("rows are written")
+	}
+}
@@ -0,0 +1,95 @@
+// Package tokenmock is a token chaincode for tests of paid lotteries.
+// it keeps a balance per account and implements the functions the lottery calls with InvokeChaincode.
+// it does not check who owns the accounts it moves tokens from, so it must not be deployed.
+package tokenmock
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Chaincode functions :
+//
+//	mint     [ account, amount ]
+//	transfer [ from, to, amount ]
+//	balance  [ account ]
+type Chaincode struct{}
+
+func (c *Chaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (c *Chaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	switch function {
+	case "mint":
+		if len(args) != 2 {
+			return shim.Error("mint takes an account and an amount")
+		}
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return add(stub, args[0], amount)
+	case "transfer":
+		if len(args) != 3 {
+			return shim.Error("transfer takes two accounts and an amount")
+		}
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		response := add(stub, args[0], -amount)
+		if response.Status != shim.OK {
+			return response
+		}
+		return add(stub, args[1], amount)
+	case "balance":
+		if len(args) != 1 {
+			return shim.Error("balance takes an account")
+		}
+		balance, err := Balance(stub, args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(strconv.FormatInt(balance, 10)))
+	}
+	return shim.Error("unknown function " + function)
+}
+
+// Balance reads the balance of the account, 0 if it never received tokens.
+func Balance(stub shim.ChaincodeStubInterface, account string) (int64, error) {
+	b, err := stub.GetState(account)
+	if err != nil || b == nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(b), 10, 64)
+}
+
+func add(stub shim.ChaincodeStubInterface, account string, amount int64) pb.Response {
+	balance, err := Balance(stub, account)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if balance+amount < 0 {
+		return shim.Error("insufficient balance of " + account)
+	}
+	err = stub.PutState(account, []byte(strconv.FormatInt(balance+amount, 10)))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func parseAmount(s string) (int64, error) {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if amount < 0 {
+		return 0, strconv.ErrRange
+	}
+	return amount, nil
+}
@@ -1,8 +1,75 @@
 package main
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
 type Transaction struct {
 	ID               string `json:"ID"`
-	SubmitterID      string `json:"submitterId"`
-	SubmitterAddress string `json:"submitterAddress"`
-	Timestamp        int64  `json:"timestamp"`
+	SubmitterID      string `json:"submitterId" metadata:",optional"`
+	SubmitterAddress string `json:"submitterAddress" metadata:",optional"`
+	Timestamp        int64  `json:"timestamp" metadata:",optional"`
+	ClientID         string `json:"clientID" metadata:",optional"` // identity of the invoking client certificate
+}
+
+// NewTransaction records the current transaction along with the invoking client identity.
+func NewTransaction(stubInterface shim.ChaincodeStubInterface, submitterID string, submitterAddress string) (Transaction, error) {
+	txTimestamp, err := stubInterface.GetTxTimestamp()
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	return Transaction{
+		ID:               stubInterface.GetTxID(),
+		SubmitterID:      submitterID,
+		SubmitterAddress: submitterAddress,
+		Timestamp:        txTimestamp.Seconds,
+		ClientID:         GetClientID(stubInterface),
+	}, nil
+}
+
+// MakeUUID derives the UUID of the index-th record the transaction creates.
+// it is made of the transaction ID, so every endorser of the transaction makes the same UUID.
+func (t Transaction) MakeUUID(index int) string {
+	hash := sha256.Sum256([]byte(t.ID + "_" + strconv.Itoa(index)))
+	return hex.EncodeToString(hash[:10])
+}
+
+// GetClientID returns the unique ID of the invoking client certificate.
+// it returns empty string when the creator is not a readable X.509 identity.
+func GetClientID(stubInterface shim.ChaincodeStubInterface) string {
+	clientID, err := cid.GetID(stubInterface)
+	if err != nil {
+		logger.Debug("cannot read client identity : " + err.Error())
+		return ""
+	}
+	return clientID
+}
+
+// ADMIN_ATTRIBUTE is the client certificate attribute of lottery administrators ( lottery.admin=true ).
+const ADMIN_ATTRIBUTE = "lottery.admin"
+
+// CheckAdmin returns ErrUnauthorized unless the invoking client is a lottery administrator.
+func CheckAdmin(stubInterface shim.ChaincodeStubInterface) error {
+	err := cid.AssertAttributeValue(stubInterface, ADMIN_ATTRIBUTE, "true")
+	if err != nil {
+		return ErrUnauthorized.WithDetail("client is not a lottery administrator")
+	}
+	return nil
+}
+
+// CheckEventManager returns ErrUnauthorized unless the invoking client created the event or is a lottery administrator.
+func CheckEventManager(stubInterface shim.ChaincodeStubInterface, event *Event, tx Transaction, action string) error {
+	if tx.ClientID != "" && tx.ClientID == event.EventCreateTx.ClientID {
+		return nil
+	}
+	if CheckAdmin(stubInterface) == nil {
+		return nil
+	}
+	return ErrUnauthorized.WithDetail("only the event creator or an administrator can " + action)
 }
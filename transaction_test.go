@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// identityStub is a MockStub invoked by an X.509 client identity, with private data deletion.
+type identityStub struct {
+	*shimtest.MockStub
+	creator   []byte
+	args      []string
+	transient map[string][]byte // transient map of the next call
+}
+
+func newIdentityStub(name string) *identityStub {
+	return &identityStub{MockStub: shimtest.NewMockStub(name, new(LotteryChaincode))}
+}
+
+// setClient switches the invoking client certificate.
+func (s *identityStub) setClient(t *testing.T, commonName string) {
+	s.setClientWithAttributes(t, commonName, nil)
+}
+
+// setClientWithAttributes switches the invoking client certificate, with the attributes of the fabric CA.
+func (s *identityStub) setClientWithAttributes(t *testing.T, commonName string, attributes map[string]string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		Issuer:       pkix.Name{CommonName: "ca"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if attributes != nil {
+		b, _ := json.Marshal(map[string]interface{}{"attrs": attributes})
+		template.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: b}}
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.creator, err = proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   "Org1MSP",
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *identityStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *identityStub) DelPrivateData(collection string, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+func (s *identityStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+// GetSignedProposal returns a proposal signed once per transaction, as every endorser of the transaction receives it.
+func (s *identityStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	signature := sha256.Sum256([]byte("signature_" + s.TxID))
+	return &pb.SignedProposal{Signature: signature[:]}, nil
+}
+
+// setPrivateData sends the participant PII in the transient map of the next call.
+func (s *identityStub) setPrivateData(information string, authInformation string) {
+	b, _ := json.Marshal(ParticipantPrivateData{Information: information, AuthInformation: authInformation})
+	s.transient = map[string][]byte{TRANSIENT_PARTICIPANT_KEY: b}
+}
+
+func (s *identityStub) GetFunctionAndParameters() (string, []string) {
+	return "invoke", s.args
+}
+
+// call invokes the chaincode with the stub itself, so the chaincode sees the overridden methods.
+func (s *identityStub) call(t *testing.T, txID string, function string, request interface{}) ([]byte, bool) {
+	b, _ := json.Marshal(request)
+	s.args = []string{function, string(b)}
+	s.MockTransactionStart(txID)
+	res := new(LotteryChaincode).Invoke(s)
+	s.MockTransactionEnd(txID)
+	s.transient = nil
+	if res.Status != shim.OK {
+		t.Log(function + " : " + res.Message)
+		return nil, false
+	}
+	return res.Payload, true
+}
+
+func TestCheckEventManager(t *testing.T) {
+	m := newIdentityStub("lottery_cc")
+	m.setClient(t, "provider")
+	event := &Event{EventCreateTx: Transaction{ClientID: GetClientID(m)}}
+
+	if err := CheckEventManager(m, event, Transaction{ClientID: GetClientID(m)}, "manage"); err != nil {
+		t.Errorf("event creator is rejected : %v", err)
+	}
+	m.setClient(t, "participant")
+	if err := CheckEventManager(m, event, Transaction{ClientID: GetClientID(m)}, "manage"); err == nil {
+		t.Error("other client is accepted")
+	}
+	if err := CheckEventManager(m, &Event{}, Transaction{}, "manage"); err == nil {
+		t.Error("client without identity is accepted as the creator without identity")
+	}
+	m.setClientWithAttributes(t, "admin", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	if err := CheckEventManager(m, event, Transaction{ClientID: GetClientID(m)}, "manage"); err != nil {
+		t.Errorf("administrator is rejected : %v", err)
+	}
+}
+
+func TestMakeUUID(t *testing.T) {
+	// every endorser of the transaction makes the same UUIDs
+	request := func() *CreateLotteryRequest {
+		return &CreateLotteryRequest{Prizes: []Prize{{Title: "first"}, {Title: "second"}}}
+	}
+	event1 := NewEvent(request(), Transaction{ID: "createTx"})
+	event2 := NewEvent(request(), Transaction{ID: "createTx"})
+	if event1.UUID != event2.UUID || event1.Prizes[1].UUID != event2.Prizes[1].UUID {
+		t.Fatal("UUIDs differ in the same transaction")
+	}
+
+	uuids := map[string]bool{event1.UUID: true, event1.Prizes[0].UUID: true, event1.Prizes[1].UUID: true}
+	other := NewEvent(request(), Transaction{ID: "otherTx"})
+	uuids[other.UUID] = true
+	if len(uuids) != 4 {
+		t.Fatal("UUIDs collide")
+	}
+}
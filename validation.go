@@ -0,0 +1,392 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/sslab-archive/block_lottery_cc/draw"
+)
+
+// request size limits. they bound the world state a single request can write.
+const (
+	MAX_UUID_LENGTH        = 64
+	MAX_NAME_LENGTH        = 256
+	MAX_CONTENTS_LENGTH    = 16 * 1024
+	MAX_URL_LENGTH         = 2048
+	MAX_HASH_LENGTH        = 256 // block hash, service provider hash, input hash and signature
+	MAX_REASON_LENGTH      = 1024
+	MAX_SUBMITTER_LENGTH   = 256
+	MAX_INFORMATION_LENGTH = 4096 // participant information and auth information
+
+	MAX_AUTH_PARAMS        = 16
+	MAX_DRAW_TYPES         = 4
+	MAX_PRIZES             = 64
+	MAX_EXCLUSION_RULES    = 8
+	MAX_ELIGIBILITY_RULES  = 8
+	MAX_ELIGIBILITY_VALUES = 64
+
+	MAX_PARTICIPANT  = 10000000
+	MAX_WINNER_NUM   = 10000
+	MAX_TOKEN_AMOUNT = 1000000000 // payout of a prize and entry fee of an event, the escrow balance stays far from overflowing
+)
+
+// FieldViolation is a request field breaking a validation rule.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validation collects the field violations of a request.
+type Validation struct {
+	violations []FieldViolation
+}
+
+// Validator is implemented by every request. txTime is the transaction timestamp in UNIX seconds.
+type Validator interface {
+	Validate(v *Validation, txTime int64)
+}
+
+// ValidateRequest validates the request at the transaction time.
+func ValidateRequest(stubInterface shim.ChaincodeStubInterface, request Validator) error {
+	txTimestamp, err := stubInterface.GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+
+	v := new(Validation)
+	request.Validate(v, txTimestamp.Seconds)
+	return v.Err()
+}
+
+// Err returns ErrInvalidRequest with every violation, or nil if the request is valid.
+// the field of the error is the first violated field.
+func (v *Validation) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return ErrInvalidRequest.WithField(v.violations[0].Field).WithViolations(v.violations)
+}
+
+func (v *Validation) Fail(field string, message string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Message: message})
+}
+
+func (v *Validation) Required(field string, value string) {
+	if value == "" {
+		v.Fail(field, "required")
+	}
+}
+
+func (v *Validation) MaxLength(field string, value string, max int) {
+	if len(value) > max {
+		v.Fail(field, "longer than "+strconv.Itoa(max)+" bytes")
+	}
+}
+
+func (v *Validation) MaxItems(field string, num int, max int) {
+	if num > max {
+		v.Fail(field, "more than "+strconv.Itoa(max)+" items")
+	}
+}
+
+func (v *Validation) Range(field string, value int64, min int64, max int64) {
+	if value < min || value > max {
+		v.Fail(field, "out of range ["+strconv.FormatInt(min, 10)+", "+strconv.FormatInt(max, 10)+"]")
+	}
+}
+
+// Future checks the UNIX timestamp is after the transaction time.
+func (v *Validation) Future(field string, value int64, txTime int64) {
+	if value <= txTime {
+		v.Fail(field, "must be after the transaction time "+strconv.FormatInt(txTime, 10))
+	}
+}
+
+// UUID checks a required UUID.
+func (v *Validation) UUID(field string, value string) {
+	v.Required(field, value)
+	v.MaxLength(field, value, MAX_UUID_LENGTH)
+}
+
+func (v *Validation) submitter(submitterID string, submitterAddress string) {
+	v.MaxLength("submitterID", submitterID, MAX_SUBMITTER_LENGTH)
+	v.MaxLength("submitterAddress", submitterAddress, MAX_SUBMITTER_LENGTH)
+}
+
+func (v *Validation) auth(authURL string, authParams []string) {
+	v.MaxLength("authURL", authURL, MAX_URL_LENGTH)
+	v.MaxItems("authParams", len(authParams), MAX_AUTH_PARAMS)
+	for idx, param := range authParams {
+		v.MaxLength("authParams["+strconv.Itoa(idx)+"]", param, MAX_NAME_LENGTH)
+	}
+}
+
+func (v *Validation) seed(targetBlock BlockInfo, serviceProviderHash string) {
+	v.MaxLength("targetBlock.blockType", string(targetBlock.BlockType), MAX_NAME_LENGTH)
+	v.MaxLength("targetBlock.hash", targetBlock.Hash, MAX_HASH_LENGTH)
+	v.MaxLength("targetBlock.header", targetBlock.Header, 2*draw.BITCOIN_HEADER_SIZE)
+	v.MaxLength("serviceProviderHash", serviceProviderHash, MAX_HASH_LENGTH)
+}
+
+func (v *Validation) prizes(prizes []Prize) {
+	v.MaxItems("prizes", len(prizes), MAX_PRIZES)
+	for idx, prize := range prizes {
+		field := "prizes[" + strconv.Itoa(idx) + "]"
+		v.MaxLength(field+".title", prize.Title, MAX_NAME_LENGTH)
+		v.MaxLength(field+".memo", prize.Memo, MAX_CONTENTS_LENGTH)
+		v.Range(field+".winnerNum", prize.WinnerNum, 1, MAX_WINNER_NUM)
+		v.Range(field+".alternateNum", prize.AlternateNum, 0, MAX_WINNER_NUM)
+		v.Range(field+".payout", prize.Payout, 0, MAX_TOKEN_AMOUNT)
+	}
+}
+
+func (v *Validation) exclusionRules(rules []ExclusionRule) {
+	v.MaxItems("exclusionRules", len(rules), MAX_EXCLUSION_RULES)
+}
+
+func (v *Validation) eligibilityRules(rules []EligibilityRule) {
+	v.MaxItems("eligibilityRules", len(rules), MAX_ELIGIBILITY_RULES)
+	for idx, rule := range rules {
+		field := "eligibilityRules[" + strconv.Itoa(idx) + "]"
+		v.MaxLength(field+".name", rule.Name, MAX_NAME_LENGTH)
+		v.MaxLength(field+".attribute", rule.Attribute, MAX_NAME_LENGTH)
+		v.MaxLength(field+".pattern", rule.Pattern, MAX_NAME_LENGTH)
+		v.MaxItems(field+".values", len(rule.Values), MAX_ELIGIBILITY_VALUES)
+		for _, value := range rule.Values {
+			v.MaxLength(field+".values", value, MAX_NAME_LENGTH)
+		}
+	}
+}
+
+func (v *Validation) participantInformation(field string, information string, authInformation string) {
+	v.MaxLength(field+".information", information, MAX_INFORMATION_LENGTH)
+	v.MaxLength(field+".authInformation", authInformation, MAX_INFORMATION_LENGTH)
+}
+
+func (r CreateLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.MaxLength("eventName", r.EventName, MAX_NAME_LENGTH)
+	v.MaxLength("contents", r.Contents, MAX_CONTENTS_LENGTH)
+	v.Future("deadlineTime", r.DeadlineTime, txTime)
+	v.Range("maxParticipant", r.MaxParticipant, 1, MAX_PARTICIPANT)
+	v.MaxItems("drawTypes", len(r.DrawTypes), MAX_DRAW_TYPES)
+	v.prizes(r.Prizes)
+	v.exclusionRules(r.ExclusionRules)
+	v.eligibilityRules(r.EligibilityRules)
+	v.auth(r.AuthURL, r.AuthParams)
+	v.MaxLength("privateCollection", r.PrivateCollection, MAX_NAME_LENGTH)
+	v.seed(r.TargetBlock, r.ServiceProviderHash)
+	v.MaxLength("tokenChaincode", r.TokenChaincode, MAX_NAME_LENGTH)
+	v.MaxLength("tokenChannel", r.TokenChannel, MAX_NAME_LENGTH)
+	v.Range("entryFee", r.EntryFee, 0, MAX_TOKEN_AMOUNT)
+	v.MaxLength("allowlistRoot", r.AllowlistRoot, MAX_HASH_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r QueryLotteryByEventIDRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+}
+
+func (r QueryLotteryByParticipantIDRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("participantUUID", r.ParticipantUUID)
+}
+
+func (r QueryLotteryByDateRangeRequest) Validate(v *Validation, txTime int64) {
+	if r.EndDateTimestamp != 0 && r.EndDateTimestamp < r.StartDateTimestamp {
+		v.Fail("endDateTimestamp", "must not be before startDateTimestamp")
+	}
+}
+
+func (r SearchLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.MaxLength("submitterID", r.SubmitterID, MAX_SUBMITTER_LENGTH)
+	v.MaxLength("prizeTitle", r.PrizeTitle, MAX_NAME_LENGTH)
+}
+
+func (r QueryLotteryRoundsRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+}
+
+func (r ParticipateLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("participant.UUID", r.Participant.UUID)
+	v.participantInformation("participant", r.Participant.Information, r.Participant.AuthInformation)
+	v.MaxItems("allowlistProof", len(r.AllowlistProof), MAX_ALLOWLIST_PROOF_STEPS)
+	for idx, step := range r.AllowlistProof {
+		v.MaxLength("allowlistProof["+strconv.Itoa(idx)+"].hash", step.Hash, MAX_HASH_LENGTH)
+	}
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r DrawLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.seed(r.TargetBlock, r.ServiceProviderHash)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r ContinueDrawRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.Range("chunkSize", r.ChunkSize, 0, MAX_DRAW_CHUNK_SIZE)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r FinalizeDrawRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r DisqualifyWinnerRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("prizeUUID", r.PrizeUUID)
+	v.UUID("participantUUID", r.ParticipantUUID)
+	v.MaxLength("reason", r.Reason, MAX_REASON_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r ClaimPrizeRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("prizeUUID", r.PrizeUUID)
+	v.UUID("participantUUID", r.ParticipantUUID)
+	v.MaxLength("signature", r.Signature, MAX_HASH_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r CloseClaimsRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r VerifyLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.MaxLength("inputHash", r.InputHash, MAX_HASH_LENGTH)
+}
+
+func (r AddLotteryRoundRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.Future("drawTime", r.DrawTime, txTime)
+	v.prizes(r.Prizes)
+	v.seed(r.TargetBlock, r.ServiceProviderHash)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r DrawLotteryRoundRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.seed(r.TargetBlock, r.ServiceProviderHash)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r CreateLotteryTemplateRequest) Validate(v *Validation, txTime int64) {
+	v.MaxLength("name", r.Name, MAX_NAME_LENGTH)
+	v.MaxLength("contents", r.Contents, MAX_CONTENTS_LENGTH)
+	v.Range("maxParticipant", r.MaxParticipant, 1, MAX_PARTICIPANT)
+	v.MaxItems("drawTypes", len(r.DrawTypes), MAX_DRAW_TYPES)
+	v.prizes(r.Prizes)
+	v.exclusionRules(r.ExclusionRules)
+	v.MaxLength("blockType", string(r.BlockType), MAX_NAME_LENGTH)
+	v.auth(r.AuthURL, r.AuthParams)
+	if r.Recurrence.MaxOccurrences < 0 {
+		v.Fail("recurrence.maxOccurrences", "cannot be negative")
+	}
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r UpdateLotteryTemplateRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("templateUUID", r.TemplateUUID)
+	r.CreateLotteryTemplateRequest.Validate(v, txTime)
+}
+
+func (r GetDrawCertificateRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+}
+
+func (r QueryParticipantPrivateDataRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("participantUUID", r.ParticipantUUID)
+}
+
+// the rows of the participants are checked one by one in the transaction, so a row does not fail the request.
+func (r BulkParticipateRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.MaxItems("participants", len(r.Participants), MAX_BULK_ROWS)
+	v.MaxLength("fileHash", r.FileHash, MAX_HASH_LENGTH)
+	if r.FileHash == "" {
+		if len(r.Participants) == 0 {
+			v.Fail("participants", "required without fileHash")
+		}
+	} else {
+		v.Range("chunkNum", int64(r.ChunkNum), 1, MAX_BULK_CHUNKS)
+		if len(r.Participants) > 0 {
+			v.Fail("participants", "not allowed with fileHash")
+		}
+	}
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r UploadBulkChunkRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.Required("fileHash", r.FileHash)
+	v.MaxLength("fileHash", r.FileHash, MAX_HASH_LENGTH)
+	v.Range("chunkIndex", int64(r.ChunkIndex), 0, MAX_BULK_CHUNKS-1)
+	v.MaxLength("data", r.Data, MAX_BULK_CHUNK_SIZE)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r SetLotteryConfigRequest) Validate(v *Validation, txTime int64) {
+	v.Range("maxBulkRows", r.MaxBulkRows, 1, MAX_BULK_ROWS)
+	if r.MaxParticipant != 0 {
+		v.Range("maxParticipant", r.MaxParticipant, 1, MAX_PARTICIPANT)
+	}
+	v.Range("maxBulkChunkSize", r.MaxBulkChunkSize, 1, MAX_BULK_CHUNK_SIZE)
+	v.Range("maxBulkChunks", r.MaxBulkChunks, 1, MAX_BULK_CHUNKS)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r WithdrawParticipationRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("participantUUID", r.ParticipantUUID)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r UpdateAllowlistRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.MaxLength("allowlistRoot", r.AllowlistRoot, MAX_HASH_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r RemoveLotteryRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r EraseParticipantDataRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+	v.UUID("participantUUID", r.ParticipantUUID)
+	v.MaxLength("reason", r.Reason, MAX_REASON_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+func (r QueryLotteryHistoryRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("eventUUID", r.EventUUID)
+}
+
+func (r QueryLotteryTemplateRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("templateUUID", r.TemplateUUID)
+	if r.Version < 0 {
+		v.Fail("version", "cannot be negative")
+	}
+}
+
+func (r SpawnFromTemplateRequest) Validate(v *Validation, txTime int64) {
+	v.UUID("templateUUID", r.TemplateUUID)
+	v.MaxLength("serviceProviderHash", r.ServiceProviderHash, MAX_HASH_LENGTH)
+	v.submitter(r.SubmitterID, r.SubmitterAddress)
+}
+
+// Validate checks the private data in the transient map, which is not a part of the request args.
+func (d ParticipantPrivateData) Validate(v *Validation, txTime int64) {
+	v.participantInformation(TRANSIENT_PARTICIPANT_KEY, d.Information, d.AuthInformation)
+	v.MaxLength(TRANSIENT_PARTICIPANT_KEY+".salt", d.Salt, MAX_HASH_LENGTH)
+}
+
+func (r MigrateEventsRequest) Validate(v *Validation, txTime int64) {
+	v.Range("pageSize", int64(r.PageSize), 1, int64(MAX_PAGE_SIZE))
+	v.MaxLength("bookmark", r.Bookmark, MAX_UUID_LENGTH)
+}
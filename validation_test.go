@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func TestValidateCreateLotteryRequest(t *testing.T) {
+	m := shimtest.NewMockStub("lottery_cc", new(LotteryChaincode))
+	m.MockTransactionStart("validateTx")
+	defer m.MockTransactionEnd("validateTx")
+	txTimestamp, _ := m.GetTxTimestamp()
+
+	request := CreateLotteryRequest{
+		EventName:      "event",
+		Contents:       strings.Repeat("a", MAX_CONTENTS_LENGTH+1),
+		DeadlineTime:   txTimestamp.Seconds,
+		MaxParticipant: 0,
+		Prizes:         make([]Prize, MAX_PRIZES+1),
+		AuthParams:     []string{"param", strings.Repeat("a", MAX_NAME_LENGTH+1)},
+		EntryFee:       MAX_TOKEN_AMOUNT + 1,
+	}
+	request.Prizes[1].Payout = MAX_TOKEN_AMOUNT + 1
+	err := ValidateRequest(m, request)
+	lotteryErr, ok := err.(*LotteryError)
+	if !ok || !ErrInvalidRequest.Is(lotteryErr) || lotteryErr.Field != "contents" {
+		t.Fatalf("unexpected error : %v", err)
+	}
+
+	violated := make(map[string]bool)
+	for _, violation := range lotteryErr.Violations {
+		violated[violation.Field] = true
+	}
+	for _, field := range []string{"contents", "deadlineTime", "maxParticipant", "prizes", "prizes[0].winnerNum", "prizes[1].payout", "entryFee", "authParams[1]"} {
+		if !violated[field] {
+			t.Errorf("%s must be violated : %+v", field, lotteryErr.Violations)
+		}
+	}
+	if violated["eventName"] || violated["authParams[0]"] {
+		t.Errorf("unexpected violations : %+v", lotteryErr.Violations)
+	}
+
+	request = CreateLotteryRequest{
+		EventName:      "event",
+		DeadlineTime:   txTimestamp.Seconds + 1,
+		MaxParticipant: MAX_PARTICIPANT,
+		Prizes:         []Prize{{Title: "prize", WinnerNum: 1}},
+	}
+	if err = ValidateRequest(m, request); err != nil {
+		t.Errorf("valid request is rejected : %v", err)
+	}
+}
+
+// transientStub is a MockStub with the transient map of the proposal.
+type transientStub struct {
+	*shimtest.MockStub
+	transient map[string][]byte
+}
+
+func (s *transientStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func TestValidateTransientPrivateData(t *testing.T) {
+	m := &transientStub{
+		MockStub: shimtest.NewMockStub("lottery_cc", new(LotteryChaincode)),
+		transient: map[string][]byte{
+			TRANSIENT_PARTICIPANT_KEY: []byte(`{"information":"` + strings.Repeat("a", MAX_INFORMATION_LENGTH+1) + `"}`),
+		},
+	}
+
+	participant := &Participant{UUID: "participant1"}
+	_, err := participant.SeparatePrivateData(m)
+	if lotteryErr, ok := err.(*LotteryError); !ok || lotteryErr.Field != TRANSIENT_PARTICIPANT_KEY+".information" {
+		t.Errorf("unexpected error : %v", err)
+	}
+}
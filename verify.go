@@ -0,0 +1,86 @@
+package main
+
+import "strconv"
+
+type VerifyResult struct {
+	EventUUID  string `json:"eventUUID"`
+	RoundIndex int64  `json:"roundIndex"` // -1 for the event draw
+	Verified   bool   `json:"verified"`
+	SeedHash   string `json:"seedHash"`
+	Mismatch   string `json:"mismatch"` // first found mismatch, empty if verified
+}
+
+// Verify re-draws the event from the recorded seed inputs and compares the results.
+func (e *Event) Verify(inputHash string) VerifyResult {
+	result := VerifyResult{
+		EventUUID:  e.UUID,
+		RoundIndex: -1,
+		SeedHash:   e.SeedHash,
+	}
+	if e.Status != STATUS_DRAWN {
+		result.Mismatch = "event is not drawn"
+		return result
+	}
+
+	result.Mismatch = verifySeed(e.SeedHash, MakeSeed(e.TargetBlock.Hash, e.ServiceProviderHash), inputHash)
+	if result.Mismatch == "" {
+		result.Mismatch = verifyPrizes(e.Prizes, e.drawingParticipants(), e.SeedHash)
+	}
+	result.Verified = result.Mismatch == ""
+	return result
+}
+
+func verifySeed(recordedSeed string, madeSeed string, inputHash string) string {
+	if recordedSeed != madeSeed {
+		return "seed hash is not made from the seed inputs"
+	}
+	if inputHash != "" && inputHash != recordedSeed {
+		return "seed hash is not matched with input hash"
+	}
+	return ""
+}
+
+// verifyPrizes draws the prizes again and replays the recorded disqualifications in order.
+// it returns the first mismatch with the recorded winners and alternates.
+func verifyPrizes(recordedPrizes []Prize, participants []Participant, seed string) string {
+	replayedPrizes := make([]Prize, len(recordedPrizes))
+	for idx, prize := range recordedPrizes {
+		replayedPrizes[idx] = Prize{
+			UUID:          prize.UUID,
+			WinnerNum:     prize.WinnerNum,
+			AlternateNum:  prize.AlternateNum,
+			ClaimDeadline: prize.ClaimDeadline,
+		}
+	}
+	drawPrizes(replayedPrizes, participants, seed, Transaction{})
+
+	for idx, prize := range recordedPrizes {
+		replayed := &replayedPrizes[idx]
+		for _, disqualification := range prize.Disqualifications {
+			err := replayed.disqualifyWinner(disqualification.Participant.UUID, disqualification.Reason, Transaction{}, disqualification.DisqualifyTx)
+			if err != nil {
+				return "prize " + prize.UUID + " : cannot replay disqualification of " + disqualification.Participant.UUID
+			}
+		}
+
+		if mismatch := compareParticipants(prize.Winners, replayed.Winners); mismatch != "" {
+			return "prize " + prize.UUID + " winners : " + mismatch
+		}
+		if mismatch := compareParticipants(prize.Alternates, replayed.Alternates); mismatch != "" {
+			return "prize " + prize.UUID + " alternates : " + mismatch
+		}
+	}
+	return ""
+}
+
+func compareParticipants(recorded []Participant, replayed []Participant) string {
+	if len(recorded) != len(replayed) {
+		return "count is not matched"
+	}
+	for idx := range recorded {
+		if recorded[idx].UUID != replayed[idx].UUID {
+			return "position " + strconv.Itoa(idx) + " is " + recorded[idx].UUID + ", expected " + replayed[idx].UUID
+		}
+	}
+	return ""
+}